@@ -0,0 +1,140 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// batchChunk is the number of words a goroutine of a Batch stems at a time.
+// Small enough to keep the goroutines busy until the end, and large enough that
+// they rarely go back for more.
+const batchChunk = 256
+
+// Batch stems many words at once, e.g., to reindex a corpus. The zero value
+// stems the words one after the other with English.
+type Batch struct {
+	// Stemmer is the algorithm, or English if nil.
+	Stemmer Stemmer
+
+	// Workers is the number of goroutines the words are spread over. 0 means
+	// runtime.GOMAXPROCS, and 1 means the calling goroutine only.
+	Workers int
+
+	// Dedup stems each distinct word only once, and gives every occurrence of
+	// the word the same stem.
+	Dedup bool
+}
+
+// StemAll returns the stems of words with English, in the same order.
+func StemAll(words []string) []string {
+	return Batch{Workers: 1}.StemAll(words)
+}
+
+// StemAllParallel is StemAll with the words spread over the given number of
+// goroutines, or runtime.GOMAXPROCS if workers is 0 or less. The stems are in
+// the same order as the words.
+func StemAllParallel(words []string, workers int) []string {
+	return Batch{Workers: max(workers, 0)}.StemAll(words)
+}
+
+// StemAll returns the stems of words, in the same order.
+func (b Batch) StemAll(words []string) []string {
+	s := b.Stemmer
+	if s == nil {
+		s = English
+	}
+
+	if !b.Dedup {
+		stems := make([]string, len(words))
+		b.stem(s, words, stems)
+		return stems
+	}
+
+	// the position of each word in the distinct words
+	var (
+		index    = make(map[string]int, len(words)/4)
+		distinct []string
+		pos      = make([]int, len(words))
+	)
+
+	for i, word := range words {
+		j, ok := index[word]
+		if !ok {
+			j = len(distinct)
+			index[word] = j
+			distinct = append(distinct, word)
+		}
+
+		pos[i] = j
+	}
+
+	stems := make([]string, len(distinct))
+	b.stem(s, distinct, stems)
+
+	all := make([]string, len(words))
+	for i, j := range pos {
+		all[i] = stems[j]
+	}
+
+	return all
+}
+
+// stem puts the stems of words in stems, which must be as long as words. The
+// goroutines take chunks of words in turn, and write the stems of each chunk
+// to the same positions in stems, so the order is kept without sorting.
+func (b Batch) stem(s Stemmer, words, stems []string) {
+	workers := b.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, (len(words)+batchChunk-1)/batchChunk)
+
+	if workers <= 1 {
+		for i, word := range words {
+			stems[i] = s.Stem(word)
+		}
+		return
+	}
+
+	var (
+		wg   sync.WaitGroup
+		next atomic.Int64 // start of the next chunk
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				start := int(next.Add(batchChunk)) - batchChunk
+				if start >= len(words) {
+					return
+				}
+
+				end := min(start+batchChunk, len(words))
+				for i, word := range words[start:end] {
+					stems[start+i] = s.Stem(word)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
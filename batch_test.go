@@ -0,0 +1,101 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingStemmer counts the words it stems.
+type countingStemmer struct {
+	Stemmer
+	n atomic.Int64
+}
+
+func (c *countingStemmer) Stem(s string) string {
+	c.n.Add(1)
+	return c.Stemmer.Stem(s)
+}
+
+func TestStemAll(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	assert.Equal(t, stems, StemAll(words))
+	assert.Equal(t, stems, StemAllParallel(words, 4))
+	assert.Equal(t, stems, StemAllParallel(words, 0))
+	assert.Equal(t, stems, StemAllParallel(words, 1000))
+	assert.Equal(t, stems, Batch{Workers: 3, Dedup: true}.StemAll(words))
+
+	assert.Empty(t, StemAll(nil))
+	assert.Empty(t, StemAllParallel(nil, 4))
+	assert.Empty(t, Batch{Dedup: true}.StemAll(nil))
+	assert.Equal(t, []string{"seawe"}, StemAllParallel([]string{"seaweed"}, 4))
+}
+
+func TestBatchDedup(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	// every word three times over
+	text := append(append(append([]string(nil), words...), words...), words...)
+	expect := append(append(append([]string(nil), stems...), stems...), stems...)
+
+	for _, workers := range []int{1, 4} {
+		c := &countingStemmer{Stemmer: English}
+
+		assert.Equal(t, expect, Batch{Stemmer: c, Workers: workers, Dedup: true}.StemAll(text))
+		assert.Equal(t, int64(len(words)), c.n.Load())
+	}
+
+	c := &countingStemmer{Stemmer: NewEnglish(WithProtected("Kubernetes"))}
+	assert.Equal(t, []string{"Kubernetes", "seawe", "Kubernetes"}, Batch{Stemmer: c, Dedup: true}.StemAll([]string{"Kubernetes", "seaweed", "Kubernetes"}))
+	assert.Equal(t, int64(2), c.n.Load())
+}
+
+func BenchmarkStemAll(b *testing.B) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		StemAll(words)
+	}
+}
+
+func BenchmarkStemAllParallel(b *testing.B) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		StemAllParallel(words, 0)
+	}
+}
+
+func BenchmarkStemAllDedupZipf(b *testing.B) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+	text := zipfWords(words, len(words))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Batch{Dedup: true}.StemAll(text)
+	}
+}
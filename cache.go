@@ -0,0 +1,217 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"hash/maphash"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxCachedWord is the length of the longest word a CachedStemmer caches, in
+// bytes. Longer words are rare, and are stemmed every time, so they don't use
+// up the cache.
+const maxCachedWord = 64
+
+// CachedStemmer wraps a Stemmer with a cache of the stems of the words it has
+// seen most recently. Natural language text is Zipfian, i.e., a few thousand
+// words make up most of it, so most words are stemmed only once.
+//
+// The cache holds at most a fixed number of words. It is split into shards, each
+// with its own lock, and each shard evicts words with the CLOCK algorithm, which
+// approximates LRU without having to reorder the words on every hit. A
+// CachedStemmer is safe for concurrent use by multiple goroutines.
+type CachedStemmer struct {
+	s      Stemmer
+	seed   maphash.Seed
+	shards []cacheShard
+}
+
+// cacheShard is one of the shards of a CachedStemmer's cache.
+type cacheShard struct {
+	mu      sync.RWMutex
+	index   map[string]int // position of each word in entries
+	entries []cacheEntry   // up to cap(entries) words, in the order the clock hand visits them
+	hand    int            // next entry the clock hand considers evicting
+
+	hits, misses atomic.Uint64
+
+	_ [64]byte // keeps the counters of neighbouring shards off the same cache line
+}
+
+// cacheEntry is a word and its stem, with the reference bit of the CLOCK algorithm.
+type cacheEntry struct {
+	word, stem string
+	ref        atomic.Bool // set on every hit, cleared as the clock hand passes
+}
+
+// CacheStats are the counters of a CachedStemmer.
+type CacheStats struct {
+	Hits   uint64 // words whose stem was found in the cache
+	Misses uint64 // words that were stemmed, whether they were cached afterwards or not
+	Len    int    // words in the cache
+}
+
+// NewCachedStemmer returns a stemmer that caches the stems of up to size words
+// from s. It panics if size is not positive.
+func NewCachedStemmer(s Stemmer, size int) *CachedStemmer {
+	if size <= 0 {
+		panic("porter2: NewCachedStemmer size must be positive")
+	}
+
+	// a few shards per P keeps contention low, while every shard holds enough
+	// words for CLOCK to make good choices
+	n := 1
+	for n < 4*runtime.GOMAXPROCS(0) && size/(2*n) >= 64 {
+		n *= 2
+	}
+
+	c := &CachedStemmer{
+		s:      s,
+		seed:   maphash.MakeSeed(),
+		shards: make([]cacheShard, n),
+	}
+
+	for i := range c.shards {
+		per := size / n
+		if i < size%n {
+			per++
+		}
+
+		c.shards[i].index = make(map[string]int, per)
+		c.shards[i].entries = make([]cacheEntry, 0, per)
+	}
+
+	return c
+}
+
+// Stem returns the stemmed version of word, from the cache if it's there.
+func (c *CachedStemmer) Stem(word string) string {
+	if len(word) > maxCachedWord {
+		return c.s.Stem(word)
+	}
+
+	sh := &c.shards[maphash.String(c.seed, word)&uint64(len(c.shards)-1)]
+
+	sh.mu.RLock()
+	i, ok := sh.index[word]
+	if ok {
+		sh.entries[i].ref.Store(true)
+		stem := sh.entries[i].stem
+		sh.mu.RUnlock()
+
+		sh.hits.Add(1)
+		return stem
+	}
+	sh.mu.RUnlock()
+
+	sh.misses.Add(1)
+
+	// word may be a slice of a much larger string, e.g., a document, and stem
+	// may be word itself, so the cache keeps copies rather than the document
+	stem := c.s.Stem(word)
+	sh.put(strings.Clone(word), strings.Clone(stem))
+
+	return stem
+}
+
+// AppendStem appends the stemmed version of word to dst and returns the
+// extended buffer. It does not allocate if word is in the cache, and dst has
+// enough capacity to hold its stem.
+func (c *CachedStemmer) AppendStem(dst, word []byte) []byte {
+	if len(word) > maxCachedWord {
+		return c.s.AppendStem(dst, word)
+	}
+
+	sh := &c.shards[maphash.Bytes(c.seed, word)&uint64(len(c.shards)-1)]
+
+	sh.mu.RLock()
+	i, ok := sh.index[string(word)]
+	if ok {
+		sh.entries[i].ref.Store(true)
+		dst = append(dst, sh.entries[i].stem...)
+		sh.mu.RUnlock()
+
+		sh.hits.Add(1)
+		return dst
+	}
+	sh.mu.RUnlock()
+
+	sh.misses.Add(1)
+
+	// word may share its underlying array with dst, as in StemBytes, so it has
+	// to be copied before it's stemmed
+	key := string(word)
+
+	n := len(dst)
+	dst = c.s.AppendStem(dst, word)
+	sh.put(key, string(dst[n:]))
+
+	return dst
+}
+
+// Stats returns the counters of the cache. They are read shard by shard while
+// the cache is in use, so they're not a consistent snapshot.
+func (c *CachedStemmer) Stats() CacheStats {
+	var st CacheStats
+
+	for i := range c.shards {
+		sh := &c.shards[i]
+
+		st.Hits += sh.hits.Load()
+		st.Misses += sh.misses.Load()
+
+		sh.mu.RLock()
+		st.Len += len(sh.entries)
+		sh.mu.RUnlock()
+	}
+
+	return st
+}
+
+// put caches the stem of word, evicting the first word the clock hand finds
+// that hasn't been used since the hand last passed it, if the shard is full.
+func (sh *cacheShard) put(word, stem string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	// another goroutine may have stemmed the same word in the meantime
+	if _, ok := sh.index[word]; ok {
+		return
+	}
+
+	if len(sh.entries) < cap(sh.entries) {
+		sh.index[word] = len(sh.entries)
+		sh.entries = append(sh.entries, cacheEntry{word: word, stem: stem})
+		return
+	}
+
+	for {
+		e := &sh.entries[sh.hand]
+		if e.ref.Swap(false) {
+			sh.hand = (sh.hand + 1) % len(sh.entries)
+			continue
+		}
+
+		delete(sh.index, e.word)
+		e.word, e.stem = word, stem
+		sh.index[word] = sh.hand
+
+		sh.hand = (sh.hand + 1) % len(sh.entries)
+		return
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedStemmerVoc(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	// twice over the vocabulary, the second time from the cache, which has room
+	// to spare as the words aren't spread evenly over the shards
+	c := NewCachedStemmer(English, 2*len(words))
+	for pass := 0; pass < 2; pass++ {
+		var dst []byte
+		for i, word := range words {
+			assert.Equal(t, stems[i], c.Stem(word), word)
+
+			dst = c.AppendStem(dst[:0], []byte(word))
+			assert.Equal(t, stems[i], string(dst), word)
+		}
+	}
+
+	st := c.Stats()
+	assert.Equal(t, uint64(len(words)), st.Misses)
+	assert.Equal(t, uint64(3*len(words)), st.Hits)
+	assert.Equal(t, len(words), st.Len)
+}
+
+func TestCachedStemmerEviction(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	c := NewCachedStemmer(English, 1000)
+	for i, word := range words {
+		assert.Equal(t, stems[i], c.Stem(word), word)
+	}
+
+	st := c.Stats()
+	assert.Equal(t, 1000, st.Len)
+	assert.Equal(t, uint64(len(words)), st.Misses)
+
+	// a word that's used often survives a pass over other words
+	c.Stem("generously")
+	for _, word := range words[:2000] {
+		c.Stem("generously")
+		c.Stem(word)
+	}
+
+	hits := c.Stats().Hits
+	c.Stem("generously")
+	assert.Equal(t, hits+1, c.Stats().Hits)
+
+	// words longer than maxCachedWord are stemmed every time
+	long := strings.Repeat("a", maxCachedWord) + "ing"
+	assert.Equal(t, Stem(long), c.Stem(long))
+	assert.Equal(t, Stem(long), string(c.AppendStem(nil, []byte(long))))
+	assert.Equal(t, hits+1, c.Stats().Hits)
+
+	assert.Panics(t, func() { NewCachedStemmer(English, 0) })
+}
+
+func TestCachedStemmerStemBytes(t *testing.T) {
+	c := NewCachedStemmer(English, 10)
+
+	// the word and dst share their underlying array, as in StemBytes
+	for i := 0; i < 2; i++ {
+		word := []byte("Generously")
+		assert.Equal(t, "generous", string(c.AppendStem(word[:0], word)))
+	}
+
+	assert.Equal(t, "generous", c.Stem("Generously"))
+}
+
+func TestCachedStemmerCopiesWords(t *testing.T) {
+	c := NewCachedStemmer(English, 10)
+
+	// the words are slices of a large document, which the cache must not keep
+	// alive; ox is its own stem
+	doc := strings.Repeat(" ", 1<<20) + "ox seaweed"
+	assert.Equal(t, "ox", c.Stem(doc[1<<20:1<<20+2]))
+	assert.Equal(t, "seawe", c.Stem(doc[1<<20+3:]))
+
+	base := uintptr(unsafe.Pointer(unsafe.StringData(doc)))
+	inDoc := func(s string) bool {
+		p := uintptr(unsafe.Pointer(unsafe.StringData(s)))
+		return base <= p && p < base+uintptr(len(doc))
+	}
+
+	n := 0
+	for i := range c.shards {
+		for j := range c.shards[i].entries {
+			e := &c.shards[i].entries[j]
+			assert.False(t, inDoc(e.word), e.word)
+			assert.False(t, inDoc(e.stem), e.stem)
+			n++
+		}
+	}
+	assert.Equal(t, 2, n)
+}
+
+func TestCachedStemmerConcurrent(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	c := NewCachedStemmer(NewEnglish(WithProtected("Kubernetes")), 500)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+
+			rnd := rand.New(rand.NewSource(seed))
+			zipf := rand.NewZipf(rnd, 1.1, 1, uint64(len(words)-1))
+
+			var dst []byte
+			for n := 0; n < 5000; n++ {
+				i := zipf.Uint64()
+				if n%2 == 0 {
+					assert.Equal(t, stems[i], c.Stem(words[i]), words[i])
+				} else {
+					dst = c.AppendStem(dst[:0], []byte(words[i]))
+					assert.Equal(t, stems[i], string(dst), words[i])
+				}
+			}
+
+			assert.Equal(t, "Kubernetes", c.Stem("Kubernetes"))
+		}(int64(g))
+	}
+	wg.Wait()
+
+	st := c.Stats()
+	assert.Equal(t, uint64(8*5001), st.Hits+st.Misses)
+	assert.True(t, st.Hits > st.Misses, "%+v", st)
+	assert.True(t, st.Len <= 500, "%+v", st)
+}
+
+func TestCachedStemmerAllocs(t *testing.T) {
+	c := NewCachedStemmer(English, 10)
+	word := []byte("generously")
+	dst := make([]byte, 0, 64)
+
+	c.AppendStem(dst, word)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		dst = c.AppendStem(dst[:0], word)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+// zipfWords returns n words from the vocabulary, drawn from a Zipf distribution
+// like the words of natural language text.
+func zipfWords(words []string, n int) []string {
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, uint64(len(words)-1))
+
+	text := make([]string, n)
+	for i := range text {
+		text[i] = words[zipf.Uint64()]
+	}
+
+	return text
+}
+
+func BenchmarkCachedStemmerVoc(b *testing.B) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+	c := NewCachedStemmer(English, 2*len(words))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range words {
+			c.Stem(word)
+		}
+	}
+}
+
+func BenchmarkEnglishStemZipf(b *testing.B) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+	text := zipfWords(words, len(words))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range text {
+			Stem(word)
+		}
+	}
+}
+
+func BenchmarkCachedStemmerZipf(b *testing.B) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+	text := zipfWords(words, len(words))
+	c := NewCachedStemmer(English, 4096)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range text {
+			c.Stem(word)
+		}
+	}
+}
+
+func BenchmarkCachedStemmerZipfParallel(b *testing.B) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+	text := zipfWords(words, len(words))
+	c := NewCachedStemmer(English, 4096)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			c.Stem(text[i%len(text)])
+		}
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// paice evaluates the registered stemmers against groups of words that belong
+// together, with Paice's understemming and overstemming indexes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/surgebase/porter2"
+	"github.com/surgebase/porter2/eval"
+	_ "github.com/surgebase/porter2/porter1"
+)
+
+var algos = flag.String("algo", "", "comma separated stemming algorithms, or all of "+strings.Join(porter2.Stemmers(), ", ")+" if empty")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: paice [flags] groups.txt\n\n")
+	fmt.Fprintf(os.Stderr, "Evaluates stemmers against groups of words, one group per line. Files ending in .gz are decompressed.\n\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	groups, err := eval.LoadGroups(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// porter2 is another name for the english stemmer, so it's left out of all
+	var names []string
+	for _, name := range porter2.Stemmers() {
+		if name != "porter2" {
+			names = append(names, name)
+		}
+	}
+
+	if *algos != "" {
+		names = strings.Split(*algos, ",")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintf(w, "stemmer\tUI\tOI\tSW\tUMT\tWMT\t\n")
+
+	for _, name := range names {
+		s, err := porter2.Lookup(strings.TrimSpace(name))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		r := eval.Paice(s, groups)
+		fmt.Fprintf(w, "%s\t%.4f\t%.6f\t%.4f\t%d\t%d\t\n", name, r.UI, r.OI, r.SW, r.UMT, r.WMT)
+	}
+
+	w.Flush()
+
+	// the pairs only depend on the groups, not the stemmer
+	r := eval.Paice(porter2.English, groups)
+	fmt.Printf("\n%d words in %d groups, %d pairs in the same group (DMT), %d pairs in different groups (DNT)\n", r.Words, r.Groups, r.DMT, r.DNT)
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/surgebase/porter2"
+	"github.com/surgebase/porter2/internal/input"
+	_ "github.com/surgebase/porter2/porter1"
+)
+
+var (
+	algo       = flag.String("algo", "english", "stemming algorithm, one of "+strings.Join(porter2.Stemmers(), ", "))
+	format     = flag.String("format", "line", "output format: line (the stem), tsv (word<tab>stem), or json (JSON lines)")
+	text       = flag.Bool("text", false, "input is free text to be split into words, rather than one word per line")
+	exceptions = flag.String("exceptions", "", "file with exceptions for the english stemmer, one invariant word, or word and stem, per line")
+	protected  = flag.String("protected", "", "file with words the english stemmer must not stem, one per line")
+	fold       = flag.Bool("fold", false, "remove accents before stemming with the english stemmer, so naïve stems like naive")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: porter2 [flags] [file ...]\n\n")
+	fmt.Fprintf(os.Stderr, "Stems the words in each file, or stdin if there's none. Files ending in .gz are decompressed.\n\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+
+	flag.Usage = usage
+	flag.Parse()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	if err := run(os.Stdout, files); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run stems the words in files, and writes them to w in the format picked by
+// the flags. What's been stemmed is written even if a file fails.
+func run(w io.Writer, files []string) error {
+	s, err := stemmer()
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+
+	emit, err := emitter(out)
+	if err != nil {
+		return err
+	}
+
+	for _, fname := range files {
+		if err := stemFile(fname, s, emit); err != nil {
+			out.Flush()
+			return fmt.Errorf("%s: %w", fname, err)
+		}
+	}
+
+	return out.Flush()
+}
+
+// emitter returns the function that writes a word and its stem to out, in the
+// format picked by the flags. The offsets are only known with -text. Words are
+// only empty for the blank lines of word per line input, which only the line
+// format writes, so its output lines up with the input.
+func emitter(out *bufio.Writer) (func(word, stem []byte, start, end int64) error, error) {
+	switch *format {
+	case "line":
+		return func(word, stem []byte, start, end int64) error {
+			out.Write(stem)
+			return out.WriteByte('\n')
+		}, nil
+
+	case "tsv":
+		return func(word, stem []byte, start, end int64) error {
+			if len(word) == 0 {
+				return nil
+			}
+
+			out.Write(word)
+			out.WriteByte('\t')
+			out.Write(stem)
+			return out.WriteByte('\n')
+		}, nil
+
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		return func(word, stem []byte, start, end int64) error {
+			if len(word) == 0 {
+				return nil
+			}
+
+			if !*text {
+				return enc.Encode(struct {
+					Word string `json:"word"`
+					Stem string `json:"stem"`
+				}{string(word), string(stem)})
+			}
+
+			return enc.Encode(struct {
+				Word  string `json:"word"`
+				Stem  string `json:"stem"`
+				Start int64  `json:"start"`
+				End   int64  `json:"end"`
+			}{string(word), string(stem), start, end})
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown format %q", *format)
+}
+
+// stemFile stems the words in the named file, and emits each word and its stem.
+func stemFile(fname string, s porter2.Stemmer, emit func(word, stem []byte, start, end int64) error) error {
+	r, err := input.OpenArg(fname)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if !*text {
+		return stemLines(r, s, emit)
+	}
+
+	tok := porter2.NewTokenizer(r, s)
+	for tok.Next() {
+		t := tok.Token()
+		if err := emit(t.Text, t.Stem, t.Start, t.End); err != nil {
+			return err
+		}
+	}
+
+	return tok.Err()
+}
+
+// stemLines stems the words in r, one per line, and emits each word and its
+// stem. Lines are trimmed, so CRLF line endings and indentation don't end up in
+// the stems, and blank lines are emitted as an empty word and stem.
+func stemLines(r io.Reader, s porter2.Stemmer, emit func(word, stem []byte, start, end int64) error) error {
+	var buf []byte
+
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		word := bytes.TrimSpace(scan.Bytes())
+
+		buf = s.AppendStem(buf[:0], word)
+		if err := emit(word, buf, 0, 0); err != nil {
+			return err
+		}
+	}
+
+	return scan.Err()
+}
+
+// stemmer returns the stemmer picked by the flags.
+func stemmer() (porter2.Stemmer, error) {
+	s, err := porter2.Lookup(*algo)
+	if err != nil {
+		return nil, err
+	}
+
+	if *exceptions == "" && *protected == "" && !*fold {
+		return s, nil
+	}
+
+	if s != porter2.English {
+		return nil, fmt.Errorf("-exceptions, -protected and -fold only work with the english stemmer, not %s", *algo)
+	}
+
+	var opts []porter2.Option
+
+	if *fold {
+		opts = append(opts, porter2.WithAccentFolding())
+	}
+
+	if *exceptions != "" {
+		ex, err := porter2.LoadExceptions(*exceptions)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, porter2.WithExceptions(ex))
+	}
+
+	if *protected != "" {
+		r, err := input.OpenArg(*protected)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		scan := bufio.NewScanner(r)
+
+		var words []string
+		for scan.Scan() {
+			if word := strings.TrimSpace(scan.Text()); word != "" {
+				words = append(words, word)
+			}
+		}
+
+		if err := scan.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", *protected, err)
+		}
+
+		opts = append(opts, porter2.WithProtected(words...))
+	}
+
+	return porter2.NewEnglish(opts...), nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/surgebase/porter2"
+)
+
+func TestStemLines(t *testing.T) {
+	in := "seaweeds\r\n\r\n  generously\t\r\n\n   \nfox\n"
+
+	var words, stems []string
+	err := stemLines(strings.NewReader(in), porter2.English, func(word, stem []byte, start, end int64) error {
+		words = append(words, string(word))
+		stems = append(stems, string(stem))
+		return nil
+	})
+
+	// blank lines are kept, so the stems line up with the words
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"seaweeds", "", "generously", "", "", "fox"}, words)
+	assert.Equal(t, []string{"seawe", "", "generous", "", "", "fox"}, stems)
+}
+
+// errWriter fails every write.
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestRun(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "words.txt")
+	assert.NoError(t, os.WriteFile(fname, []byte("seaweeds\n\ngenerously\n"), 0644))
+
+	var out bytes.Buffer
+	assert.NoError(t, run(&out, []string{fname}))
+	assert.Equal(t, "seawe\n\ngenerous\n", out.String())
+
+	// the stems of the files before the one that fails are still written
+	out.Reset()
+	err := run(&out, []string{fname, filepath.Join(t.TempDir(), "missing.txt")})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, "seawe\n\ngenerous\n", out.String())
+
+	assert.EqualError(t, run(errWriter{}, []string{fname}), "broken pipe")
+}
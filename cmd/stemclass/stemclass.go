@@ -0,0 +1,201 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// stemclass groups the words of a vocabulary into equivalence classes, i.e.,
+// the words that share a stem, to audit a stemmer for overstemming.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/surgebase/porter2"
+	"github.com/surgebase/porter2/internal/input"
+	_ "github.com/surgebase/porter2/porter1"
+)
+
+var (
+	algo   = flag.String("algo", "english", "stemming algorithm, one of "+strings.Join(porter2.Stemmers(), ", "))
+	format = flag.String("format", "line", "output format: line (size<tab>stem<tab>words), or json (JSON lines)")
+	top    = flag.Int("top", 0, "write only the largest classes, or all if 0")
+)
+
+// filter picks the suspicious classes.
+type filter struct {
+	min    int     // fewest words in a class
+	spread int     // least difference in length between the longest and shortest word, in runes
+	ratio  float64 // greatest length of the stem, over the length of the longest word
+}
+
+var flt filter
+
+func init() {
+	flag.IntVar(&flt.min, "min", 2, "write only the classes with at least this many words")
+	flag.IntVar(&flt.spread, "spread", 0, "write only the classes whose longest and shortest words differ in length by at least this many characters")
+	flag.Float64Var(&flt.ratio, "ratio", 0, "write only the classes whose stem is at most this fraction of the length of the longest word, e.g., 0.5")
+}
+
+// class is the words that share a stem.
+type class struct {
+	Stem  string   `json:"stem"`
+	Words []string `json:"words"` // sorted by length, and then alphabetically
+}
+
+// classes groups the distinct words by their stems with s, the largest class
+// first. Classes of the same size are sorted by stem.
+func classes(s porter2.Stemmer, words []string) []class {
+	index := make(map[string]int) // position of each stem in cs
+	seen := make(map[string]bool)
+
+	var cs []class
+
+	for _, word := range words {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+
+		stem := s.Stem(word)
+
+		i, ok := index[stem]
+		if !ok {
+			i = len(cs)
+			index[stem] = i
+			cs = append(cs, class{Stem: stem})
+		}
+
+		cs[i].Words = append(cs[i].Words, word)
+	}
+
+	for _, c := range cs {
+		sort.Slice(c.Words, func(i, j int) bool {
+			li, lj := utf8.RuneCountInString(c.Words[i]), utf8.RuneCountInString(c.Words[j])
+			if li != lj {
+				return li < lj
+			}
+			return c.Words[i] < c.Words[j]
+		})
+	}
+
+	sort.Slice(cs, func(i, j int) bool {
+		if len(cs[i].Words) != len(cs[j].Words) {
+			return len(cs[i].Words) > len(cs[j].Words)
+		}
+		return cs[i].Stem < cs[j].Stem
+	})
+
+	return cs
+}
+
+// match returns true if c passes all of the filter's tests.
+func (f filter) match(c class) bool {
+	if len(c.Words) == 0 || len(c.Words) < f.min {
+		return false
+	}
+
+	shortest := utf8.RuneCountInString(c.Words[0])
+	longest := utf8.RuneCountInString(c.Words[len(c.Words)-1])
+
+	if longest-shortest < f.spread {
+		return false
+	}
+
+	if f.ratio > 0 && float64(utf8.RuneCountInString(c.Stem)) > f.ratio*float64(longest) {
+		return false
+	}
+
+	return true
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: stemclass [flags] [file ...]\n\n")
+	fmt.Fprintf(os.Stderr, "Groups the words in each file, or stdin if there's none, by their stems, and writes the classes\n")
+	fmt.Fprintf(os.Stderr, "largest first. Each file has one word per line. Files ending in .gz are decompressed.\n\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+
+	flag.Usage = usage
+	flag.Parse()
+
+	if *format != "line" && *format != "json" {
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	s, err := porter2.Lookup(*algo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var words []string
+
+	for _, fname := range files {
+		r, err := input.OpenArg(fname)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		scan := bufio.NewScanner(r)
+		for scan.Scan() {
+			if word := strings.TrimSpace(scan.Text()); word != "" {
+				words = append(words, word)
+			}
+		}
+
+		if err := scan.Err(); err != nil {
+			log.Fatalf("%s: %v", fname, err)
+		}
+
+		r.Close()
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+
+	n := 0
+	for _, c := range classes(s, words) {
+		if !flt.match(c) {
+			continue
+		}
+
+		if *top > 0 && n == *top {
+			break
+		}
+		n++
+
+		if *format == "json" {
+			enc.Encode(c)
+			continue
+		}
+
+		fmt.Fprintf(out, "%d\t%s\t%s\n", len(c.Words), c.Stem, strings.Join(c.Words, " "))
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/surgebase/porter2"
+)
+
+func TestClasses(t *testing.T) {
+	words := []string{"general", "generous", "generously", "generation", "seaweed", "seaweeds", "seaweed", "fox"}
+
+	cs := classes(porter2.English, words)
+	assert.Equal(t, []class{
+		{Stem: "generous", Words: []string{"generous", "generously"}},
+		{Stem: "seawe", Words: []string{"seaweed", "seaweeds"}},
+		{Stem: "fox", Words: []string{"fox"}},
+		{Stem: "general", Words: []string{"general"}},
+		{Stem: "generat", Words: []string{"generation"}},
+	}, cs)
+}
+
+func TestFilter(t *testing.T) {
+	univers := class{Stem: "univers", Words: []string{"universe", "universal", "university", "universities"}}
+	seawe := class{Stem: "seawe", Words: []string{"seaweed", "seaweeds"}}
+	fox := class{Stem: "fox", Words: []string{"fox"}}
+
+	tests := []struct {
+		f      filter
+		expect []bool
+	}{
+		{filter{min: 2}, []bool{true, true, false}},
+		{filter{min: 1}, []bool{true, true, true}},
+		{filter{min: 2, spread: 3}, []bool{true, false, false}},
+		{filter{min: 2, ratio: 0.6}, []bool{true, false, false}},
+		{filter{min: 2, ratio: 0.7}, []bool{true, true, false}},
+		{filter{min: 5}, []bool{false, false, false}},
+	}
+
+	for _, tt := range tests {
+		for i, c := range []class{univers, seawe, fox} {
+			assert.Equal(t, tt.expect[i], tt.f.match(c), "%+v %s", tt.f, c.Stem)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// finalComment matches the comment that names the suffix of a final state.
+var finalComment = regexp.MustCompile(`^//\s*(\S+) - final$`)
+
+// check writes a report on the spec in fname, whose step function is called
+// name, to w. The report has the suffixes that are already in the spec, which
+// are never reached, the suffixes that are shadowed by longer ones that end
+// with them, and the size of the state machine.
+//
+// If src isn't "", the cases of the switch f block of the step function in the
+// Go file src are compared with the final states of the state machine, which
+// finds the cases that are out of date with the spec, such as after suffixes
+// are reordered.
+//
+// It returns the number of problems found, i.e., the suffixes that are never
+// reached and the cases that don't match the spec. Shadowed suffixes are how
+// the longest suffix wins, so they're only reported.
+func check(w io.Writer, fname, name, src string, opt options) (int, error) {
+	if filepath.Ext(fname) == ".sbl" {
+		return 0, fmt.Errorf("%s: -check only works with suffix specs, use -format spec to get them", fname)
+	}
+
+	sp, _, err := readSpec(fname, scanSpec)
+	if err != nil {
+		return 0, err
+	}
+
+	problems := 0
+
+	for _, ru := range sp.dups {
+		fmt.Fprintf(w, "%s:%d: suffix %q is already on line %d, so it's never reached\n", fname, ru.line, ru.suffix, sp.find(ru.suffix).line)
+		problems++
+	}
+
+	for _, ru := range sp.rules {
+		var longer []string
+		for _, o := range sp.rules {
+			if o != ru && strings.HasSuffix(o.suffix, ru.suffix) {
+				longer = append(longer, o.suffix)
+			}
+		}
+
+		if len(longer) > 0 {
+			fmt.Fprintf(w, "%s:%d: suffix %q is shadowed by %s\n", fname, ru.line, ru.suffix, strings.Join(longer, ", "))
+		}
+	}
+
+	var (
+		nodes  = sp.automaton(opt.minimize)
+		finals = make(map[int][]string) // suffixes of each final state
+		depth  int
+	)
+
+	for _, n := range nodes {
+		if n.f {
+			finals[n.s] = n.ws
+		}
+	}
+
+	for _, ru := range sp.rules {
+		depth = max(depth, utf8.RuneCountInString(ru.suffix))
+	}
+
+	fmt.Fprintf(w, "%s: %d suffixes, %d states, %d final, maximum depth %d\n", fname, len(sp.rules), len(nodes), len(finals), depth)
+
+	if src == "" {
+		return problems, nil
+	}
+
+	cases, err := switchCases(src, name)
+	if err != nil {
+		return problems, err
+	}
+
+	seen := make(map[int]bool)
+
+	for _, c := range cases {
+		for _, s := range c.states {
+			seen[s] = true
+
+			suffixes, ok := finals[s]
+			if !ok {
+				fmt.Fprintf(w, "%s: case %d of %s isn't a final state in %s\n", c.pos, s, name, fname)
+				problems++
+				continue
+			}
+
+			// hand written cases don't always name their suffixes
+			if len(c.suffixes) == 0 {
+				continue
+			}
+
+			for _, suffix := range suffixes {
+				if !c.suffixes[suffix] {
+					fmt.Fprintf(w, "%s: case %d of %s is for suffix %q in %s, which isn't in its comments\n", c.pos, s, name, suffix, fname)
+					problems++
+				}
+			}
+		}
+	}
+
+	var missing []int
+	for s := range finals {
+		if !seen[s] {
+			missing = append(missing, s)
+		}
+	}
+	sort.Ints(missing)
+
+	for _, s := range missing {
+		fmt.Fprintf(w, "%s: %s has no case for final state %d, suffix %s\n", src, name, s, strings.Join(finals[s], ", "))
+		problems++
+	}
+
+	return problems, nil
+}
+
+// switchCase is a case of the switch f block of a step function.
+type switchCase struct {
+	pos      token.Position
+	states   []int
+	suffixes map[string]bool // the suffixes named in the comments of the case
+}
+
+// switchCases returns the cases of the switch f block in the function called
+// name in the Go file src.
+func switchCases(src, name string) ([]switchCase, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, src, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var fn *ast.FuncDecl
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.FuncDecl); ok && d.Name.Name == name && d.Body != nil {
+			fn = d
+			break
+		}
+	}
+
+	if fn == nil {
+		return nil, fmt.Errorf("%s: no function %s", src, name)
+	}
+
+	var sw *ast.SwitchStmt
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if s, ok := n.(*ast.SwitchStmt); ok && sw == nil {
+			if tag, ok := s.Tag.(*ast.Ident); ok && tag.Name == "f" {
+				sw = s
+			}
+		}
+		return sw == nil
+	})
+
+	if sw == nil {
+		return nil, fmt.Errorf("%s: %s has no switch f block", src, name)
+	}
+
+	var cases []switchCase
+	clauses := sw.Body.List
+
+	for i, stmt := range clauses {
+		clause := stmt.(*ast.CaseClause)
+		c := switchCase{pos: fset.Position(clause.Pos()), suffixes: make(map[string]bool)}
+
+		for _, e := range clause.List {
+			lit, ok := e.(*ast.BasicLit)
+			if !ok || lit.Kind != token.INT {
+				return nil, fmt.Errorf("%s: case of %s isn't a state number", fset.Position(e.Pos()), name)
+			}
+
+			s, err := strconv.Atoi(lit.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", fset.Position(e.Pos()), err)
+			}
+
+			c.states = append(c.states, s)
+		}
+
+		// the comments of a case run until the next one, as a case with only
+		// comments ends at its colon
+		end := sw.Body.Rbrace
+		if i+1 < len(clauses) {
+			end = clauses[i+1].Pos()
+		}
+
+		for _, group := range file.Comments {
+			if group.Pos() < clause.Pos() || group.End() > end {
+				continue
+			}
+
+			for _, comment := range group.List {
+				if m := finalComment.FindStringSubmatch(comment.Text); m != nil {
+					c.suffixes[m[1]] = true
+				}
+			}
+		}
+
+		cases = append(cases, c)
+	}
+
+	return cases, nil
+}
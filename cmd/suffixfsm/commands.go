@@ -0,0 +1,1093 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The Snowball commands that don't fit in a suffix spec are compiled into Go
+// expressions over an sblEnv, whose methods are the commands, e.g.,
+//
+//	(hop 2 <-'i') or <-'ie'
+//
+// in backward mode becomes
+//
+//	z.set(&c0, z.c) && (z.hopB(2) && z.slice("i") || z.set(&z.c, c0) && z.slice("ie"))
+//
+// where c0 saves the cursor for or to go back to. A sequence of commands is
+// joined by &&, so it stops at the first one that fails, as in Snowball. The
+// integers p1 and p2, i.e., the first two, are the regions r1 and r2, and the
+// routines defined as $p1 <= cursor and $p2 <= cursor are the tests r1 <= z.c
+// and r2 <= z.c. Other routines are compiled into Go functions of their own.
+//
+// The commands that are understood are strings, groupings and non, [ and ],
+// next, hop with a number, delete, <-, <+, insert and attach, among with or
+// without substring, the prefixes not, test, try, do, fail, repeat, gopast and
+// goto, or, and, atlimit, tolimit, atmark, setmark and tomark, the comparison
+// and assignment of p1 and p2 with $, true, false, and routine calls. The rest,
+// such as booleans, setlimit and backwards, are an error.
+
+// sblCmd is a Snowball command.
+type sblCmd struct {
+	op    string     // the command, e.g., gopast, or ' for a string, grouping, call, and ( for a list
+	text  string     // the string, the letters of a grouping, or the routine or integer it's applied to
+	n     int        // the number of letters of hop
+	rel   string     // the comparison or assignment of $, e.g., <= or =
+	val   string     // what $ compares its integer with or sets it to, e.g., cursor
+	args  []*sblCmd  // the commands of a list, or that the command is applied to
+	among [][]string // the strings of an among, grouped by their action
+	acts  []*sblCmd  // the action of each group, or nil if it has none
+	of    *sblCmd    // the among of a substring, or the substring of an among, if any
+	tok   int        // the first token, for errors
+}
+
+// commands parses the commands from the i'th token to end, exclusive, into a list.
+func (p *sblParser) commands(i, end int) (*sblCmd, error) {
+	list := &sblCmd{op: "(", tok: i}
+
+	for i < end {
+		c, next, err := p.command(i)
+		if err != nil {
+			return nil, err
+		}
+
+		list.args = append(list.args, c)
+		i = next
+	}
+
+	return list, nil
+}
+
+// command parses the command at the i'th token, with those that are joined to
+// it by or and and, and returns the index of the token that follows.
+func (p *sblParser) command(i int) (*sblCmd, int, error) {
+	c, i, err := p.unary(i)
+
+	for err == nil && (p.is(i, "or") || p.is(i, "and")) {
+		var right *sblCmd
+
+		op := p.toks[i].text
+		if right, i, err = p.unary(i + 1); err == nil {
+			c = &sblCmd{op: op, args: []*sblCmd{c, right}, tok: c.tok}
+		}
+	}
+
+	return c, i, err
+}
+
+// unary parses the command at the i'th token, with the one it's applied to if
+// it's a prefix, e.g., not, and returns the index of the token that follows.
+func (p *sblParser) unary(i int) (*sblCmd, int, error) {
+	if i >= len(p.toks) {
+		return nil, i, p.errorf(i, "missing command")
+	}
+
+	tok := p.toks[i]
+	c := &sblCmd{op: tok.text, tok: i}
+
+	if tok.kind == 's' {
+		c.op, c.text = "'", tok.text
+		return c, i + 1, nil
+	}
+
+	switch tok.text {
+	case "(":
+		closing, err := p.match(i)
+		if err != nil {
+			return nil, i, err
+		}
+
+		list, err := p.commands(i+1, closing)
+		return list, closing + 1, err
+
+	case "[", "]", "next", "delete", "atlimit", "tolimit", "true", "false":
+		return c, i + 1, nil
+
+	case "substring":
+		p.substring = c
+		return c, i + 1, nil
+
+	case "among":
+		return p.parseAmong(i)
+
+	case "not", "test", "try", "do", "fail", "repeat", "gopast", "goto":
+		arg, next, err := p.unary(i + 1)
+		c.args = []*sblCmd{arg}
+		return c, next, err
+
+	case "hop":
+		n, err := strconv.Atoi(p.text(i + 1))
+		if err != nil {
+			return nil, i, p.errorf(i, "hop is only supported with a number")
+		}
+
+		c.n = n
+		return c, i + 2, nil
+
+	case "<-", "<+", "insert", "attach":
+		if i+1 >= len(p.toks) || p.toks[i+1].kind != 's' {
+			return nil, i, p.errorf(i, "%s is only supported with a string", tok.text)
+		}
+
+		c.text = p.toks[i+1].text
+		return c, i + 2, nil
+
+	case "non":
+		j := i + 1
+		if p.is(j, "-") {
+			j++
+		}
+
+		letters, ok := p.groupings[p.text(j)]
+		if !ok {
+			return nil, i, p.errorf(i, "non must be followed by a grouping")
+		}
+
+		c.text = letters
+		return c, j + 1, nil
+
+	case "atmark", "setmark", "tomark":
+		c.text = p.text(i + 1)
+		return c, i + 2, nil
+
+	// $p1 = limit, or $p1 <= cursor
+	case "$":
+		c.text = p.text(i + 1)
+
+		// the lexer has no tokens for >= and !=
+		j := i + 2
+		switch {
+		case p.is(j, "=") || p.is(j, "==") || p.is(j, "<=") || p.is(j, "<"):
+			c.rel = p.toks[j].text
+		case p.is(j, ">") && p.is(j+1, "="), p.is(j, "!") && p.is(j+1, "="):
+			c.rel = p.toks[j].text + "="
+			j++
+		case p.is(j, ">"):
+			c.rel = ">"
+		default:
+			return nil, i, p.errorf(i, "unsupported $%s", c.text)
+		}
+
+		c.val = p.text(j + 1)
+		return c, j + 2, nil
+	}
+
+	if tok.kind == 'n' {
+		if letters, ok := p.groupings[tok.text]; ok {
+			c.op, c.text = "grouping", letters
+			return c, i + 1, nil
+		}
+
+		if _, ok := p.routines[tok.text]; ok {
+			c.op, c.text = "call", tok.text
+			return c, i + 1, nil
+		}
+	}
+
+	return nil, i, p.errorf(i, "unsupported command %s", tok.text)
+}
+
+// parseAmong parses the among at the i'th token, which goes with the last
+// substring that has no among yet, if any.
+func (p *sblParser) parseAmong(i int) (*sblCmd, int, error) {
+	c := &sblCmd{op: "among", tok: i, of: p.substring}
+	if p.substring != nil {
+		p.substring.of, p.substring = c, nil
+	}
+
+	if !p.is(i+1, "(") {
+		return nil, i, p.errorf(i, "among must be followed by (")
+	}
+
+	closing, err := p.match(i + 1)
+	if err != nil {
+		return nil, i, err
+	}
+
+	var group []string
+
+	for j := i + 2; j < closing; j++ {
+		switch tok := p.toks[j]; {
+		case tok.kind == 's':
+			group = append(group, tok.text)
+
+		case p.is(j, "(") && len(group) > 0:
+			end, err := p.match(j)
+			if err != nil {
+				return nil, i, err
+			}
+
+			act, err := p.commands(j+1, end)
+			if err != nil {
+				return nil, i, err
+			}
+
+			c.among, c.acts, group = append(c.among, group), append(c.acts, act), nil
+			j = end
+
+		default:
+			return nil, i, p.errorf(j, "unsupported %s in among", tok.text)
+		}
+	}
+
+	if len(group) > 0 {
+		c.among, c.acts = append(c.among, group), append(c.acts, nil)
+	}
+
+	return c, closing + 1, nil
+}
+
+// text returns the text of the i'th token, or "" past the end.
+func (p *sblParser) text(i int) string {
+	if i >= len(p.toks) {
+		return ""
+	}
+
+	return p.toks[i].text
+}
+
+// sblGen compiles Snowball commands into the body of a Go function.
+type sblGen struct {
+	p        *sblParser
+	backward bool               // backward mode, where the cursor moves towards lb
+	vars     []string           // the saved cursors and among results, e.g., c0 and k1
+	reads    map[string]bool    // the regions that are read, e.g., r1
+	sets     map[string]bool    // the regions that are set
+	finds    map[*sblCmd]string // the variable with the result of each substring
+}
+
+// gen returns an sblGen for a routine in backward mode or not.
+func (p *sblParser) gen(backward bool) *sblGen {
+	return &sblGen{
+		p:        p,
+		backward: backward,
+		reads:    make(map[string]bool),
+		sets:     make(map[string]bool),
+		finds:    make(map[*sblCmd]string),
+	}
+}
+
+// newVar returns a new variable starting with prefix, c for cursors and k for
+// among results.
+func (g *sblGen) newVar(prefix string) string {
+	v := fmt.Sprintf("%s%d", prefix, len(g.vars))
+	g.vars = append(g.vars, v)
+	return v
+}
+
+// regions returns the regions that are read or set, in order.
+func (g *sblGen) regions() []string {
+	var regions []string
+	for _, r := range []string{"r1", "r2"} {
+		if g.reads[r] || g.sets[r] {
+			regions = append(regions, r)
+		}
+	}
+
+	return regions
+}
+
+// region returns the region of the integer of c, marking it as read, or set if set is true.
+func (g *sblGen) region(c *sblCmd, set bool) (string, error) {
+	for k, name := range g.p.ints[:min(len(g.p.ints), 2)] {
+		if name == c.text {
+			r := fmt.Sprintf("r%d", k+1)
+			if set {
+				g.sets[r] = true
+			} else {
+				g.reads[r] = true
+			}
+			return r, nil
+		}
+	}
+
+	return "", g.p.errorf(c.tok, "integer %s isn't one of the first two, which are the regions", c.text)
+}
+
+// body returns the variables that c needs, and the expressions of its
+// commands, which are run in order until one of them fails.
+func (g *sblGen) body(c *sblCmd) (string, []string, error) {
+	cmds := []*sblCmd{c}
+	if c.op == "(" {
+		cmds = c.args
+	}
+
+	var exprs []string
+	for _, c := range cmds {
+		e, err := g.expr(c)
+		if err != nil {
+			return "", nil, err
+		}
+		exprs = append(exprs, e)
+	}
+
+	vars := ""
+	if len(g.vars) > 0 {
+		vars = fmt.Sprintf("var %s int\n", strings.Join(g.vars, ", "))
+	}
+
+	return vars, exprs, nil
+}
+
+// expr returns the Go expression for c, which tells whether it succeeds.
+func (g *sblGen) expr(c *sblCmd) (string, error) {
+	// the methods of backward mode end in B
+	var (
+		b     = ""
+		limit = "z.l"
+		bra   = "z.bra"
+		ket   = "z.ket"
+	)
+
+	if g.backward {
+		b, limit, bra, ket = "B", "z.lb", "z.ket", "z.bra"
+	}
+
+	switch c.op {
+	case "(":
+		var exprs []string
+		for _, arg := range c.args {
+			e, err := g.expr(arg)
+			if err != nil {
+				return "", err
+			}
+			exprs = append(exprs, e)
+		}
+
+		switch len(exprs) {
+		case 0:
+			return "true", nil
+		case 1:
+			return exprs[0], nil
+		}
+
+		return "(" + strings.Join(exprs, " && ") + ")", nil
+
+	case "'":
+		return fmt.Sprintf("z.eq%s(%s)", b, strconv.Quote(c.text)), nil
+
+	case "grouping":
+		return fmt.Sprintf("z.in%s(%s)", b, strconv.Quote(c.text)), nil
+
+	case "non":
+		return fmt.Sprintf("z.out%s(%s)", b, strconv.Quote(c.text)), nil
+
+	case "next":
+		return fmt.Sprintf("z.next%s()", b), nil
+
+	case "hop":
+		return fmt.Sprintf("z.hop%s(%d)", b, c.n), nil
+
+	case "[":
+		return fmt.Sprintf("z.set(&%s, z.c)", bra), nil
+
+	case "]":
+		return fmt.Sprintf("z.set(&%s, z.c)", ket), nil
+
+	case "delete":
+		return `z.slice("")`, nil
+
+	case "<-":
+		return fmt.Sprintf("z.slice(%s)", strconv.Quote(c.text)), nil
+
+	// in backward mode, insert leaves the cursor where it is, and attach moves it
+	case "<+", "insert", "attach":
+		if (c.op == "attach") != g.backward {
+			return fmt.Sprintf("z.attach(%s)", strconv.Quote(c.text)), nil
+		}
+		return fmt.Sprintf("z.insert(%s)", strconv.Quote(c.text)), nil
+
+	case "atlimit":
+		return fmt.Sprintf("(z.c == %s)", limit), nil
+
+	case "tolimit":
+		return fmt.Sprintf("z.set(&z.c, %s)", limit), nil
+
+	case "true", "false":
+		return c.op, nil
+
+	case "atmark", "setmark", "tomark":
+		r, err := g.region(c, c.op == "setmark")
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case c.op == "atmark":
+			return fmt.Sprintf("(z.c == %s)", r), nil
+		case c.op == "setmark":
+			return fmt.Sprintf("z.set(&%s, z.c)", r), nil
+		case g.backward:
+			return fmt.Sprintf("(z.c >= %s && z.set(&z.c, %s))", r, r), nil
+		}
+		return fmt.Sprintf("(z.c <= %s && z.set(&z.c, %s))", r, r), nil
+
+	case "$":
+		r, err := g.region(c, c.rel == "=")
+		if err != nil {
+			return "", err
+		}
+
+		val := c.val
+		switch _, isNum := strconv.Atoi(val); {
+		case val == "limit":
+			val = limit
+		case val == "cursor":
+			val = "z.c"
+		case val == "size":
+			val = "len(z.rs)"
+		case isNum != nil:
+			// another region
+			if val, err = g.region(&sblCmd{text: val, tok: c.tok}, false); err != nil {
+				return "", err
+			}
+		}
+
+		if c.rel == "=" {
+			return fmt.Sprintf("z.set(&%s, %s)", r, val), nil
+		}
+		return fmt.Sprintf("(%s %s %s)", r, c.rel, val), nil
+
+	case "substring":
+		if c.of == nil {
+			return "", g.p.errorf(c.tok, "substring without among")
+		}
+
+		k := g.newVar("k")
+		g.finds[c] = k
+		return fmt.Sprintf("z.find%s(&%s, %s)", b, k, sblGroups(c.of.among)), nil
+
+	case "among":
+		return g.among(c, b)
+
+	case "call":
+		if r := g.p.regions[c.text]; r != "" {
+			g.reads[r] = true
+			return fmt.Sprintf("(%s <= z.c)", r), nil
+		}
+
+		fn, err := g.p.helper(c.text, c.tok, g.backward)
+		if err != nil {
+			return "", err
+		}
+
+		for _, r := range fn.regions {
+			g.reads[r] = true
+		}
+		return fmt.Sprintf("%s(%s)", fn.name, strings.Join(append([]string{"z"}, fn.regions...), ", ")), nil
+
+	case "or", "and":
+		left, err := g.expr(c.args[0])
+		if err != nil {
+			return "", err
+		}
+
+		right, err := g.expr(c.args[1])
+		if err != nil {
+			return "", err
+		}
+
+		v := g.newVar("c")
+		if c.op == "or" {
+			return fmt.Sprintf("(z.set(&%s, z.c) && (%s || z.set(&z.c, %s) && %s))", v, left, v, right), nil
+		}
+		return fmt.Sprintf("(z.set(&%s, z.c) && %s && z.set(&z.c, %s) && %s)", v, left, v, right), nil
+
+	case "not", "test", "try", "do", "fail":
+		arg, err := g.expr(c.args[0])
+		if err != nil {
+			return "", err
+		}
+
+		if c.op == "fail" {
+			return fmt.Sprintf("(%s && false)", arg), nil
+		}
+
+		v := g.newVar("c")
+		switch c.op {
+		case "not":
+			return fmt.Sprintf("(z.set(&%s, z.c) && !%s && z.set(&z.c, %s))", v, arg, v), nil
+		case "test":
+			return fmt.Sprintf("(z.set(&%s, z.c) && %s && z.set(&z.c, %s))", v, arg, v), nil
+		case "try":
+			return fmt.Sprintf("(z.set(&%s, z.c) && (%s || z.set(&z.c, %s)))", v, arg, v), nil
+		}
+		return fmt.Sprintf("(z.set(&%s, z.c) && (%s || true) && z.set(&z.c, %s))", v, arg, v), nil
+
+	case "repeat", "gopast", "goto":
+		arg, err := g.expr(c.args[0])
+		if err != nil {
+			return "", err
+		}
+
+		method := map[string]string{"repeat": "repeat", "gopast": "gopast" + b, "goto": "goTo" + b}[c.op]
+		return fmt.Sprintf("z.%s(func() bool { return %s })", method, arg), nil
+	}
+
+	return "", g.p.errorf(c.tok, "unsupported command %s", c.op)
+}
+
+// among returns the expression for the among c, which runs the action of the
+// group of strings that its substring found, and finds them first if it has
+// no substring.
+func (g *sblGen) among(c *sblCmd, b string) (string, error) {
+	var find string
+
+	k, ok := g.finds[c.of]
+	if c.of == nil || !ok {
+		k = g.newVar("k")
+		find = fmt.Sprintf("z.find%s(&%s, %s)", b, k, sblGroups(c.among))
+	}
+
+	var (
+		acts  []string
+		acted bool
+	)
+
+	for i, act := range c.acts {
+		if act == nil {
+			acts = append(acts, fmt.Sprintf("%s == %d", k, i+1))
+			continue
+		}
+
+		e, err := g.expr(act)
+		if err != nil {
+			return "", err
+		}
+
+		acts, acted = append(acts, fmt.Sprintf("%s == %d && %s", k, i+1, e)), true
+	}
+
+	switch {
+	case !acted && find != "":
+		return find, nil
+	case !acted:
+		return "true", nil
+	case find != "":
+		return fmt.Sprintf("(%s && (%s))", find, strings.Join(acts, " || ")), nil
+	}
+
+	return "(" + strings.Join(acts, " || ") + ")", nil
+}
+
+// sblGroups returns the Go literal for the strings of an among, grouped by action.
+func sblGroups(groups [][]string) string {
+	var lits []string
+	for _, g := range groups {
+		var strs []string
+		for _, s := range g {
+			strs = append(strs, strconv.Quote(s))
+		}
+		lits = append(lits, "{"+strings.Join(strs, ", ")+"}")
+	}
+
+	return "[][]string{" + strings.Join(lits, ", ") + "}"
+}
+
+// sblFunc is a routine compiled into a Go function, which takes the regions
+// it reads after the sblEnv.
+type sblFunc struct {
+	name    string
+	regions []string
+}
+
+// helper returns the Go function of the routine name, which is called at the
+// tok'th token in backward mode or not, and compiles it the first time.
+func (p *sblParser) helper(name string, tok int, backward bool) (*sblFunc, error) {
+	r := p.routines[name]
+	if r.backward != backward {
+		return nil, p.errorf(tok, "%s is called in a different mode than it's defined in", name)
+	}
+
+	if fn, ok := p.funcs[name]; ok {
+		if fn == nil {
+			return nil, p.errorf(tok, "%s calls itself, which isn't supported", name)
+		}
+		return fn, nil
+	}
+	p.funcs[name] = nil
+
+	c, _, err := p.command(r.tok)
+	if err != nil {
+		return nil, err
+	}
+
+	g := p.gen(backward)
+
+	vars, exprs, err := g.body(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(g.sets) > 0 {
+		return nil, p.errorf(tok, "%s sets the regions, so it can't be called", name)
+	}
+
+	fn := &sblFunc{name: "sbl" + strings.ToUpper(goName(name)[:1]) + goName(name)[1:] + p.tag, regions: g.regions()}
+
+	params := fmt.Sprintf("z *%s[T]", p.env())
+	if len(fn.regions) > 0 {
+		params += ", " + strings.Join(fn.regions, ", ") + " int"
+	}
+
+	ret := "true"
+	if len(exprs) > 0 {
+		ret = strings.Join(exprs, " &&\n")
+	}
+
+	fmt.Fprintf(&p.helpers, "\n// %s is the routine %s of %s.\n", fn.name, name, p.base)
+	fmt.Fprintf(&p.helpers, "func %s[T letter](%s) bool {\n%sreturn %s\n}\n", fn.name, params, vars, ret)
+
+	p.funcs[name] = fn
+	return fn, nil
+}
+
+// compileAction writes the Go function fn for the action between the tokens
+// open and closing, exclusive, of the suffixes of routine, which is called
+// with the suffix found by among as the slice, and the cursor before it. It
+// returns the regions fn takes.
+func (p *sblParser) compileAction(open, closing int, fn, routine string, suffixes []string) ([]string, error) {
+	c, err := p.commands(open+1, closing)
+	if err != nil {
+		return nil, err
+	}
+
+	g := p.gen(true)
+
+	vars, exprs, err := g.body(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(g.sets) > 0 {
+		return nil, p.errorf(open, "an action that sets the regions isn't supported")
+	}
+
+	var quoted []string
+	for _, s := range suffixes {
+		quoted = append(quoted, strconv.Quote(s))
+	}
+
+	regions := g.regions()
+
+	params := "rs []T, m int"
+	if len(regions) > 0 {
+		params = "rs []T, " + strings.Join(append([]string{"m"}, regions...), ", ") + " int"
+	}
+
+	what := "suffixes"
+	if len(quoted) == 1 {
+		what = "suffix"
+	}
+
+	fmt.Fprintf(&p.code, "\n// %s performs the action of %s in %s for the %s %s.\n", fn, routine, p.base, what, strings.Join(quoted, ", "))
+	fmt.Fprintf(&p.code, "func %s[T letter](%s) []T {\n", fn, params)
+	fmt.Fprintf(&p.code, "z := &%s[T]{rs: rs, c: len(rs) - m, l: len(rs), bra: len(rs) - m, ket: len(rs)}\n%s", p.env(), vars)
+	if len(exprs) > 0 {
+		fmt.Fprintf(&p.code, "\n_ = %s\n\n", strings.Join(exprs, " &&\n"))
+	}
+	fmt.Fprintf(&p.code, "return z.rs\n}\n")
+
+	return regions, nil
+}
+
+// compileStep writes the step function fn for the backward mode routine,
+// which has no among for a state machine, so all of it is compiled.
+func (p *sblParser) compileStep(routine, fn string) error {
+	c, _, err := p.command(p.routines[routine].tok)
+	if err != nil {
+		return err
+	}
+
+	g := p.gen(true)
+
+	vars, exprs, err := g.body(c)
+	if err != nil {
+		return err
+	}
+
+	if len(g.sets) > 0 {
+		return p.errorf(c.tok, "%s sets the regions, which isn't supported in backward mode", routine)
+	}
+
+	params := "rs []T"
+	if regions := g.regions(); len(regions) > 0 {
+		params += ", " + strings.Join(regions, ", ") + " int"
+	}
+
+	fmt.Fprintf(&p.code, "\n// %s is the routine %s of %s.\n", fn, routine, p.base)
+	fmt.Fprintf(&p.code, "func %s[T letter](%s) []T {\n", fn, params)
+	fmt.Fprintf(&p.code, "z := &%s[T]{rs: rs, c: len(rs), l: len(rs)}\n%s", p.env(), vars)
+	if len(exprs) > 0 {
+		fmt.Fprintf(&p.code, "\n_ = %s\n\n", strings.Join(exprs, " &&\n"))
+	}
+	fmt.Fprintf(&p.code, "return z.rs\n}\n")
+
+	return nil
+}
+
+// compileRegions writes the Go function fn for the forward mode routine that
+// sets the regions, e.g., mark_regions, which returns them.
+func (p *sblParser) compileRegions(routine, fn string) error {
+	c, _, err := p.command(p.routines[routine].tok)
+	if err != nil {
+		return err
+	}
+
+	g := p.gen(false)
+
+	vars, exprs, err := g.body(c)
+	if err != nil {
+		return err
+	}
+
+	regions := strings.Join(g.regions(), ", ")
+
+	fmt.Fprintf(&p.code, "\n// %s is the routine %s of %s, which returns the regions of rs.\n", fn, routine, p.base)
+	fmt.Fprintf(&p.code, "func %s[T letter](rs []T) (%s int) {\n", fn, regions)
+	fmt.Fprintf(&p.code, "z := &%s[T]{rs: rs, l: len(rs)}\n%s", p.env(), vars)
+	fmt.Fprintf(&p.code, "\n_ = %s\n\n", strings.Join(exprs, " &&\n"))
+	fmt.Fprintf(&p.code, "return %s\n}\n", regions)
+
+	return nil
+}
+
+// env returns the name of the sblEnv type, with the tag of the generated functions.
+func (p *sblParser) env() string {
+	return "sblEnv" + p.tag
+}
+
+// sblRuntime is the Go code of the sblEnv that the compiled commands run on,
+// which is written once after the functions compiled from a Snowball source.
+const sblRuntime = `
+// sblEnv is the state of the routines compiled from Snowball by suffixfsm: the
+// word rs, the cursor c between the limits lb and l, and the slice from bra to
+// ket that delete and <- replace. Its methods are the Snowball commands, and
+// those ending in B are for backward mode, where the cursor moves towards lb.
+type sblEnv[T letter] struct {
+	rs       []T
+	c, lb, l int
+	bra, ket int
+}
+
+// set sets *p to v, e.g., the cursor to where it was saved.
+func (z *sblEnv[T]) set(p *int, v int) bool {
+	*p = v
+	return true
+}
+
+// has tells whether r is one of letters.
+func (z *sblEnv[T]) has(letters string, r T) bool {
+	for _, l := range letters {
+		if rune(r) == l {
+			return true
+		}
+	}
+
+	return false
+}
+
+// eq tells whether s follows the cursor, and moves the cursor past it.
+func (z *sblEnv[T]) eq(s string) bool {
+	c := z.c
+	for _, r := range s {
+		if c >= z.l || rune(z.rs[c]) != r {
+			return false
+		}
+		c++
+	}
+
+	z.c = c
+	return true
+}
+
+// eqB tells whether s precedes the cursor, and moves the cursor before it.
+func (z *sblEnv[T]) eqB(s string) bool {
+	c := z.c
+	for range s {
+		c--
+	}
+
+	if c < z.lb {
+		return false
+	}
+
+	i := c
+	for _, r := range s {
+		if rune(z.rs[i]) != r {
+			return false
+		}
+		i++
+	}
+
+	z.c = c
+	return true
+}
+
+// in tells whether the letter after the cursor is one of letters, and moves
+// the cursor past it.
+func (z *sblEnv[T]) in(letters string) bool {
+	if z.c >= z.l || !z.has(letters, z.rs[z.c]) {
+		return false
+	}
+
+	z.c++
+	return true
+}
+
+// inB is in for the letter before the cursor.
+func (z *sblEnv[T]) inB(letters string) bool {
+	if z.c <= z.lb || !z.has(letters, z.rs[z.c-1]) {
+		return false
+	}
+
+	z.c--
+	return true
+}
+
+// out tells whether the letter after the cursor isn't one of letters, and
+// moves the cursor past it.
+func (z *sblEnv[T]) out(letters string) bool {
+	if z.c >= z.l || z.has(letters, z.rs[z.c]) {
+		return false
+	}
+
+	z.c++
+	return true
+}
+
+// outB is out for the letter before the cursor.
+func (z *sblEnv[T]) outB(letters string) bool {
+	if z.c <= z.lb || z.has(letters, z.rs[z.c-1]) {
+		return false
+	}
+
+	z.c--
+	return true
+}
+
+// next moves the cursor past the letter after it.
+func (z *sblEnv[T]) next() bool {
+	return z.hop(1)
+}
+
+// nextB moves the cursor before the letter before it.
+func (z *sblEnv[T]) nextB() bool {
+	return z.hopB(1)
+}
+
+// hop moves the cursor past the n letters after it.
+func (z *sblEnv[T]) hop(n int) bool {
+	if z.l-z.c < n {
+		return false
+	}
+
+	z.c += n
+	return true
+}
+
+// hopB moves the cursor before the n letters before it.
+func (z *sblEnv[T]) hopB(n int) bool {
+	if z.c-z.lb < n {
+		return false
+	}
+
+	z.c -= n
+	return true
+}
+
+// gopast moves the cursor forward until f succeeds, and leaves it where f does.
+func (z *sblEnv[T]) gopast(f func() bool) bool {
+	for {
+		c := z.c
+		if f() {
+			return true
+		}
+
+		z.c = c
+		if !z.next() {
+			return false
+		}
+	}
+}
+
+// gopastB is gopast backwards.
+func (z *sblEnv[T]) gopastB(f func() bool) bool {
+	for {
+		c := z.c
+		if f() {
+			return true
+		}
+
+		z.c = c
+		if !z.nextB() {
+			return false
+		}
+	}
+}
+
+// goTo moves the cursor forward until f succeeds, and leaves it where f started.
+func (z *sblEnv[T]) goTo(f func() bool) bool {
+	for {
+		c := z.c
+		if f() {
+			z.c = c
+			return true
+		}
+
+		z.c = c
+		if !z.next() {
+			return false
+		}
+	}
+}
+
+// goToB is goTo backwards.
+func (z *sblEnv[T]) goToB(f func() bool) bool {
+	for {
+		c := z.c
+		if f() {
+			z.c = c
+			return true
+		}
+
+		z.c = c
+		if !z.nextB() {
+			return false
+		}
+	}
+}
+
+// repeat runs f until it fails, and leaves the cursor where the last run that
+// succeeded did.
+func (z *sblEnv[T]) repeat(f func() bool) bool {
+	for {
+		c := z.c
+		if !f() {
+			z.c = c
+			return true
+		}
+	}
+}
+
+// find finds the longest of the strings in groups that follows the cursor,
+// sets *k to its group, counting from 1, and moves the cursor past it.
+func (z *sblEnv[T]) find(k *int, groups [][]string) bool {
+	c, end := z.c, -1
+	for i, g := range groups {
+		for _, s := range g {
+			if z.eq(s) && z.c > end {
+				end, *k = z.c, i+1
+			}
+			z.c = c
+		}
+	}
+
+	if end < 0 {
+		return false
+	}
+
+	z.c = end
+	return true
+}
+
+// findB is find for the strings that precede the cursor, which moves before it.
+func (z *sblEnv[T]) findB(k *int, groups [][]string) bool {
+	c, start := z.c, z.c+1
+	for i, g := range groups {
+		for _, s := range g {
+			if z.eqB(s) && z.c < start {
+				start, *k = z.c, i+1
+			}
+			z.c = c
+		}
+	}
+
+	if start > c {
+		return false
+	}
+
+	z.c = start
+	return true
+}
+
+// slice replaces the slice from bra to ket with s.
+func (z *sblEnv[T]) slice(s string) bool {
+	if z.bra < 0 || z.bra > z.ket || z.ket > len(z.rs) {
+		return false
+	}
+
+	z.replace(z.bra, z.ket, s)
+	return true
+}
+
+// insert inserts s at the cursor, and moves the cursor past it.
+func (z *sblEnv[T]) insert(s string) bool {
+	c := z.c
+	adj := z.replace(c, c, s)
+
+	if c <= z.bra {
+		z.bra += adj
+	}
+	if c <= z.ket {
+		z.ket += adj
+	}
+
+	return true
+}
+
+// attach inserts s at the cursor, and leaves the cursor before it.
+func (z *sblEnv[T]) attach(s string) bool {
+	c := z.c
+	z.insert(s)
+	z.c = c
+	return true
+}
+
+// replace replaces the letters from bra to ket with s, and moves the limit and
+// the cursor, if it's after bra, by the difference in length, which it returns.
+func (z *sblEnv[T]) replace(bra, ket int, s string) int {
+	n := 0
+	for range s {
+		n++
+	}
+
+	l, adj := len(z.rs), n-(ket-bra)
+	for range adj {
+		z.rs = append(z.rs, 0)
+	}
+
+	copy(z.rs[ket+adj:], z.rs[ket:l])
+	z.rs = z.rs[:l+adj]
+
+	i := bra
+	for _, r := range s {
+		z.rs[i] = T(r)
+		i++
+	}
+
+	z.l += adj
+	if z.c >= ket {
+		z.c += adj
+	} else if z.c > bra {
+		z.c = bra
+	}
+
+	return adj
+}
+`
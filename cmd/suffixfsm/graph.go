@@ -0,0 +1,101 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// dot writes the state machine for sp as a Graphviz digraph called name. Each
+// state is a node labelled with its number, final states are double circles
+// that are also labelled with their suffix, and the edges are labelled with the
+// letter that leads to the next state. The letters are read from the end of the
+// word, so the path to a final state spells its suffix backwards. If minimize is
+// true, the minimized state machine is drawn, and each final state is labelled
+// with all the suffixes that end in it.
+func (sp *spec) dot(w io.Writer, name string, minimize bool) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", strconv.Quote(name))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=circle];\n")
+
+	nodes := sp.automaton(minimize)
+
+	for _, n := range nodes {
+		if n.f {
+			fmt.Fprintf(&b, "\t%d [shape=doublecircle, label=%s];\n", n.s, strconv.Quote(fmt.Sprintf("%d\n%s", n.s, n.suffixes())))
+		} else {
+			fmt.Fprintf(&b, "\t%d [label=\"%d\"];\n", n.s, n.s)
+		}
+	}
+
+	for _, n := range nodes {
+		for _, c := range n.c {
+			fmt.Fprintf(&b, "\t%d -> %d [label=%s];\n", n.s, c.s, strconv.Quote(string(c.r)))
+		}
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaid writes the state machine for sp as a Mermaid flowchart titled name,
+// laid out the same way as dot.
+func (sp *spec) mermaid(w io.Writer, name string, minimize bool) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "---\ntitle: %s\n---\n", name)
+	b.WriteString("flowchart LR\n")
+
+	nodes := sp.automaton(minimize)
+
+	for _, n := range nodes {
+		if n.f {
+			fmt.Fprintf(&b, "\ts%d(((\"%d<br/>%s\")))\n", n.s, n.s, mermaidEscape(n.suffixes()))
+		} else {
+			fmt.Fprintf(&b, "\ts%d((\"%d\"))\n", n.s, n.s)
+		}
+	}
+
+	for _, n := range nodes {
+		for _, c := range n.c {
+			fmt.Fprintf(&b, "\ts%d -->|\"%s\"| s%d\n", n.s, mermaidEscape(string(c.r)), c.s)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// suffixes returns the suffixes that end in n, separated by commas.
+func (n *node) suffixes() string {
+	if len(n.ws) == 0 {
+		return n.w
+	}
+
+	return strings.Join(n.ws, ", ")
+}
+
+// mermaidEscape escapes the characters that can't appear in a quoted Mermaid
+// label as entity codes.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
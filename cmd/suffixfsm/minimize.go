@@ -0,0 +1,102 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// minimize merges the equivalent nodes of the suffix tree, and returns the
+// states of the resulting state machine, numbered in the order of the first
+// node merged into each of them. The root is still state 0.
+//
+// Two nodes are equivalent if they're both final for rules with the same action,
+// or both not final, and their children are equivalent and reached by the same
+// letters. The tree has no cycles, so the nodes are classified from the leaves
+// up, in one pass. This is the same as minimizing the automaton, where the final
+// states are told apart by their action as well.
+//
+// The letter leading to a state is kept in the node, but a merged state can be
+// reached by different letters, so each child of a merged state is a copy of
+// the state it leads to, with the letter of that transition.
+func (sp *spec) minimize(nodes []*node) []*node {
+	regions := sp.regions()
+
+	// the action of each suffix, which is the body of its case in the step function
+	actions := make(map[string]string)
+	for _, ru := range sp.rules {
+		actions[ru.suffix], _ = ru.body(regions)
+	}
+
+	var (
+		classes  = make(map[string]int) // class of each signature
+		class    = make(map[*node]int)  // class of each node
+		classify func(n *node) int
+	)
+
+	classify = func(n *node) int {
+		var edges []string
+		for _, c := range n.c {
+			edges = append(edges, fmt.Sprintf("%q:%d", c.r, classify(c)))
+		}
+		sort.Strings(edges)
+
+		sig := fmt.Sprintf("%t %q %s", n.f, actions[n.w], strings.Join(edges, " "))
+
+		k, ok := classes[sig]
+		if !ok {
+			k = len(classes)
+			classes[sig] = k
+		}
+
+		class[n] = k
+		return k
+	}
+
+	classify(nodes[0])
+
+	// the first node of each class becomes its state, in the order of the tree
+	var (
+		states []*node
+		firsts []*node               // first node of each state
+		merged = make(map[int]*node) // state of each class
+	)
+
+	for _, n := range nodes {
+		k := class[n]
+
+		st, ok := merged[k]
+		if !ok {
+			st = &node{r: n.r, f: n.f, s: len(states), w: n.w}
+			states, firsts = append(states, st), append(firsts, n)
+			merged[k] = st
+		}
+
+		if n.f {
+			st.ws = append(st.ws, n.w)
+		}
+	}
+
+	for i, st := range states {
+		for _, c := range firsts[i].c {
+			to := merged[class[c]]
+			st.c = append(st.c, &node{r: c.r, f: to.f, s: to.s, w: to.w, ws: to.ws})
+		}
+	}
+
+	return states
+}
@@ -0,0 +1,856 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Snowball algorithm definition (.sbl) is compiled into a suffix spec for
+// each among of its routines that is preceded by [substring], which is how
+// Snowball searches for the longest suffix, e.g.,
+//
+//	define Step_2 as (
+//	    [substring] R1 among (
+//	        'tional'  (<-'tion')
+//	        'ogi'     ('l' <-'og')
+//	        'li'      (valid_LI delete)
+//	    )
+//	)
+//
+// becomes the spec for step2, where valid_LI is a grouping:
+//
+//	tional	tion	R1
+//	ogi	og	R1	after:l
+//	li	-	R1	after:cdeghkmnrt
+//
+// The actions that fit in a spec are delete and <-, preceded by the regions,
+// and then by one letter, or letters joined by or, or a grouping, which is one
+// of the letters in it. The regions are the routines defined as $p1 <= cursor
+// and $p2 <= cursor, for the first two integers. Any other action is compiled
+// into a Go function, which the spec calls with do:, e.g.,
+//
+//	'ied' 'ies' ((hop 2 <-'i') or <-'ie')
+//
+// becomes
+//
+//	ied	=	do:step1aAction2
+//	ies	=	do:step1aAction2
+//
+// where step1aAction2 is the action compiled as in commands.go, and so are
+// the routines it calls, e.g., shortv. A comment that starts with suffixfsm:
+// followed by the replacement and conditions of the suffix in its spec takes
+// the place of the action, e.g., for commands that aren't compiled:
+//
+//	's' (setlimit tomark p1 for delete) // suffixfsm: - R1
+//
+// Only the amongs of backward mode routines are suffixes. Those preceded by
+// atlimit match whole words, like the exception lists of english, and are
+// skipped. A backward mode routine without [substring] among, which isn't a
+// region or called by another backward mode routine, e.g., Step_1c, is
+// compiled into a step function of its own, and so is the forward mode routine
+// that sets the regions, e.g., mark_regions, into a function that returns
+// them. The other forward mode routines, e.g., prelude and stem, which call
+// the steps, are written by hand.
+
+// sblToken is a token of a Snowball source.
+type sblToken struct {
+	kind rune   // 'n' for names, 's' for strings, or the punctuation itself, e.g., '(', or '<' for <- and <+
+	text string // the name, the string with its escapes replaced, or the punctuation
+	line int
+}
+
+// sblLexer splits a Snowball source into tokens.
+type sblLexer struct {
+	src        []rune
+	pos, line  int
+	esc        [2]rune           // string escape characters, from stringescapes
+	defs       map[string]string // string definitions, from stringdef
+	directives map[int]string    // suffixfsm: comments by line
+}
+
+// lexSnowball returns the tokens of the Snowball source src, and the
+// suffixfsm: directives in its comments by line.
+func lexSnowball(src string) ([]sblToken, map[int]string, error) {
+	lx := &sblLexer{
+		src:        []rune(src),
+		line:       1,
+		defs:       map[string]string{"'": "'"},
+		directives: make(map[int]string),
+	}
+
+	var toks []sblToken
+
+	for {
+		tok, ok, err := lx.next()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !ok {
+			return toks, lx.directives, nil
+		}
+
+		// stringdef name [hex|decimal] 'value'
+		if tok.kind == 's' {
+			if k := len(toks); k >= 2 && toks[k-2].text == "stringdef" {
+				lx.defs[toks[k-1].text] = tok.text
+			} else if k >= 3 && toks[k-3].text == "stringdef" {
+				def, err := sblCodes(toks[k-1].text, tok)
+				if err != nil {
+					return nil, nil, err
+				}
+				lx.defs[toks[k-2].text] = def
+			}
+		}
+
+		toks = append(toks, tok)
+
+		if tok.kind == 'n' && tok.text == "stringescapes" {
+			lx.skipSpace()
+			if lx.pos+2 > len(lx.src) {
+				return nil, nil, fmt.Errorf("line %d: stringescapes needs two characters", lx.line)
+			}
+
+			lx.esc = [2]rune{lx.src[lx.pos], lx.src[lx.pos+1]}
+			lx.pos += 2
+		}
+	}
+}
+
+// sblCodes returns the string for the hex or decimal codes in tok, which is
+// how stringdef defines letters that are hard to type.
+func sblCodes(base string, tok sblToken) (string, error) {
+	b := 16
+	switch base {
+	case "hex":
+	case "decimal":
+		b = 10
+	default:
+		return "", fmt.Errorf("line %d: unknown stringdef %s", tok.line, base)
+	}
+
+	var rs []rune
+	for _, code := range strings.Fields(tok.text) {
+		r, err := strconv.ParseUint(code, b, 32)
+		if err != nil {
+			return "", fmt.Errorf("line %d: bad stringdef code %q", tok.line, code)
+		}
+		rs = append(rs, rune(r))
+	}
+
+	return string(rs), nil
+}
+
+// skipSpace skips white space and comments, and records the suffixfsm: directives.
+func (lx *sblLexer) skipSpace() {
+	for lx.pos < len(lx.src) {
+		switch r := lx.src[lx.pos]; {
+		case r == '\n':
+			lx.line++
+			lx.pos++
+
+		case unicode.IsSpace(r):
+			lx.pos++
+
+		case lx.peek("//"):
+			start := lx.pos + 2
+			for lx.pos < len(lx.src) && lx.src[lx.pos] != '\n' {
+				lx.pos++
+			}
+
+			text := strings.TrimSpace(string(lx.src[start:lx.pos]))
+			if d, ok := strings.CutPrefix(text, "suffixfsm:"); ok {
+				lx.directives[lx.line] = strings.TrimSpace(d)
+			}
+
+		case lx.peek("/*"):
+			lx.pos += 2
+			for lx.pos < len(lx.src) && !lx.peek("*/") {
+				if lx.src[lx.pos] == '\n' {
+					lx.line++
+				}
+				lx.pos++
+			}
+			lx.pos += 2
+
+		default:
+			return
+		}
+	}
+}
+
+// peek tells whether the source continues with s.
+func (lx *sblLexer) peek(s string) bool {
+	rs := []rune(s)
+	if lx.pos+len(rs) > len(lx.src) {
+		return false
+	}
+
+	return string(lx.src[lx.pos:lx.pos+len(rs)]) == s
+}
+
+// next returns the next token, or false at the end of the source.
+func (lx *sblLexer) next() (sblToken, bool, error) {
+	lx.skipSpace()
+
+	if lx.pos >= len(lx.src) {
+		return sblToken{}, false, nil
+	}
+
+	var (
+		r     = lx.src[lx.pos]
+		start = lx.pos
+		tok   = sblToken{line: lx.line}
+	)
+
+	switch {
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		for lx.pos < len(lx.src) && (lx.src[lx.pos] == '_' || unicode.IsLetter(lx.src[lx.pos]) || unicode.IsDigit(lx.src[lx.pos])) {
+			lx.pos++
+		}
+		tok.kind, tok.text = 'n', string(lx.src[start:lx.pos])
+
+	case r == '\'':
+		s, err := lx.str()
+		if err != nil {
+			return tok, false, err
+		}
+		tok.kind, tok.text = 's', s
+
+	case lx.peek("<-") || lx.peek("<+") || lx.peek("<=") || lx.peek("=>") || lx.peek("=="):
+		lx.pos += 2
+		tok.kind, tok.text = r, string(lx.src[start:lx.pos])
+
+	default:
+		lx.pos++
+		tok.kind, tok.text = r, string(r)
+	}
+
+	return tok, true, nil
+}
+
+// str reads a string, and replaces its escapes with their stringdef.
+func (lx *sblLexer) str() (string, error) {
+	var b strings.Builder
+
+	line := lx.line
+	lx.pos++ // opening quote
+
+	for lx.pos < len(lx.src) {
+		r := lx.src[lx.pos]
+
+		switch {
+		case r == '\'':
+			lx.pos++
+			return b.String(), nil
+
+		case r == '\n':
+			return "", fmt.Errorf("line %d: unterminated string", line)
+
+		case lx.esc[0] != 0 && r == lx.esc[0]:
+			end := lx.pos + 1
+			for end < len(lx.src) && lx.src[end] != lx.esc[1] && lx.src[end] != '\n' {
+				end++
+			}
+
+			if end >= len(lx.src) || lx.src[end] != lx.esc[1] {
+				return "", fmt.Errorf("line %d: unterminated escape", line)
+			}
+
+			name := string(lx.src[lx.pos+1 : end])
+			if name == string(lx.esc[0]) {
+				b.WriteString(name)
+			} else if def, ok := lx.defs[name]; ok {
+				b.WriteString(def)
+			} else {
+				return "", fmt.Errorf("line %d: undefined escape %c%s%c", line, lx.esc[0], name, lx.esc[1])
+			}
+
+			lx.pos = end + 1
+
+		default:
+			b.WriteRune(r)
+			lx.pos++
+		}
+	}
+
+	return "", fmt.Errorf("line %d: unterminated string", line)
+}
+
+// sblParser turns the tokens of a Snowball source into suffix specs, and
+// compiles the routines that don't fit in them into Go.
+type sblParser struct {
+	fname      string
+	base       string // the name of the file, for the doc comments of the Go functions
+	toks       []sblToken
+	directives map[int]string
+	ints       []string              // the integers, the first two of which are the regions
+	regions    map[string]string     // regions by the name of their routine, e.g., R1 is r1
+	groupings  map[string]string     // letters of the groupings by their name
+	backward   [][2]int              // the tokens of each backwardmode, from ( to )
+	routines   map[string]sblRoutine // the routines by their name
+	called     map[string]bool       // the routines called by backward mode routines
+	rename     map[string]string     // new names of the step functions, or - to skip them
+	renamed    map[string]bool       // the names in rename that were found
+	tag        string                // appended to the names of the Go functions
+
+	substring *sblCmd             // the last substring parsed that has no among yet
+	funcs     map[string]*sblFunc // the routines compiled into Go functions, or nil while they're compiled
+	code      bytes.Buffer        // the Go functions of the actions, steps and regions
+	helpers   bytes.Buffer        // the Go functions of the routines they call
+}
+
+// sblRoutine is a routine of a Snowball source.
+type sblRoutine struct {
+	tok, end int  // its tokens after as, up to end, exclusive
+	backward bool // whether it's in backwardmode
+}
+
+// loadSnowball reads the Snowball source in fname, and returns a spec for each
+// among that searches for the longest suffix, along with the names of their
+// step functions, and the Go code of the routines compiled for them. The name
+// is the routine's in lower case without underscores, e.g., step2 for Step_2,
+// followed by a number if it has more than one among. The names can be changed
+// with opt.rename, e.g., step1a to step0, or mapped to - to skip the among,
+// e.g., if its step function is written by hand, and so can the names of the
+// steps that are compiled whole, and of the function that returns the regions,
+// which is the routine's name in camel case, e.g., markRegions.
+func loadSnowball(fname string, opt options) ([]*spec, []string, []byte, error) {
+	src, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	toks, directives, err := lexSnowball(string(src))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %v", fname, err)
+	}
+
+	p := &sblParser{
+		fname:      fname,
+		base:       filepath.Base(fname),
+		toks:       toks,
+		directives: directives,
+		regions:    make(map[string]string),
+		groupings:  make(map[string]string),
+		routines:   make(map[string]sblRoutine),
+		called:     make(map[string]bool),
+		rename:     opt.rename,
+		renamed:    make(map[string]bool),
+		tag:        opt.tag,
+		funcs:      make(map[string]*sblFunc),
+	}
+
+	specs, names, funcs, err := p.parse()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for from := range opt.rename {
+		if !p.renamed[from] {
+			return nil, nil, nil, fmt.Errorf("%s: no step %s to rename", fname, from)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range append(names, funcs...) {
+		if seen[name] {
+			return nil, nil, nil, fmt.Errorf("%s: more than one step is called %s", fname, name)
+		}
+		seen[name] = true
+	}
+
+	if len(specs) == 0 && len(funcs) == 0 {
+		return nil, nil, nil, fmt.Errorf("%s: no steps found", fname)
+	}
+
+	if p.code.Len() == 0 {
+		return specs, names, nil, nil
+	}
+
+	// gofmt the functions as part of a file, as sp.generate does
+	const pkg = "package p\n\n"
+
+	code := pkg + p.code.String() + p.helpers.String() + strings.ReplaceAll(sblRuntime, "sblEnv", p.env())
+
+	formatted, err := format.Source([]byte(code))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: formatting the compiled routines: %v\n%s", fname, err, code)
+	}
+
+	return specs, names, bytes.TrimPrefix(formatted, []byte(pkg)), nil
+}
+
+// errorf returns an error at the line of the i'th token.
+func (p *sblParser) errorf(i int, format string, args ...interface{}) error {
+	line := 0
+	if i < len(p.toks) {
+		line = p.toks[i].line
+	} else if len(p.toks) > 0 {
+		line = p.toks[len(p.toks)-1].line
+	}
+
+	return fmt.Errorf("%s:%d: %s", p.fname, line, fmt.Sprintf(format, args...))
+}
+
+// is tells whether the i'th token is text.
+func (p *sblParser) is(i int, text string) bool {
+	return i < len(p.toks) && p.toks[i].text == text && p.toks[i].kind != 's'
+}
+
+// match returns the index of the parenthesis that closes the one at i.
+func (p *sblParser) match(i int) (int, error) {
+	depth := 0
+	for j := i; j < len(p.toks); j++ {
+		switch {
+		case p.is(j, "("):
+			depth++
+		case p.is(j, ")"):
+			depth--
+			if depth == 0 {
+				return j, nil
+			}
+		}
+	}
+
+	return 0, p.errorf(i, "unbalanced parenthesis")
+}
+
+// parse finds the integers, regions, groupings and routines, and then returns
+// the specs of the amongs and the names of their step functions, along with
+// the names of the Go functions of the routines that are compiled whole.
+func (p *sblParser) parse() ([]*spec, []string, []string, error) {
+	for i := 0; i < len(p.toks); i++ {
+		switch {
+		case p.is(i, "integers") && p.is(i+1, "("):
+			end, err := p.match(i + 1)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			for _, tok := range p.toks[i+2 : end] {
+				p.ints = append(p.ints, tok.text)
+			}
+
+		// define R1 as $p1 <= cursor
+		case p.is(i, "define") && p.is(i+2, "as") && p.is(i+3, "$") && p.is(i+5, "<=") && p.is(i+6, "cursor"):
+			for k, name := range p.ints[:min(len(p.ints), 2)] {
+				if p.is(i+4, name) {
+					p.regions[p.toks[i+1].text] = fmt.Sprintf("r%d", k+1)
+				}
+			}
+
+		case p.is(i, "backwardmode") && p.is(i+1, "("):
+			end, err := p.match(i + 1)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			p.backward = append(p.backward, [2]int{i + 1, end})
+
+		// define v 'aeiouy', or a union of groupings and strings
+		case p.is(i, "define") && i+2 < len(p.toks) && !p.is(i+2, "as"):
+			var letters strings.Builder
+
+			for j := i + 2; j < len(p.toks); j += 2 {
+				tok := p.toks[j]
+				if tok.kind == 's' {
+					letters.WriteString(tok.text)
+				} else if g, ok := p.groupings[tok.text]; ok {
+					letters.WriteString(g)
+				} else {
+					return nil, nil, nil, p.errorf(j, "grouping %s isn't a union of letters", p.toks[i+1].text)
+				}
+
+				if !p.is(j+1, "+") {
+					break
+				}
+			}
+
+			p.groupings[p.toks[i+1].text] = letters.String()
+		}
+	}
+
+	var order []string
+
+	for i := 0; i < len(p.toks); i++ {
+		if !p.is(i, "define") || !p.is(i+2, "as") {
+			continue
+		}
+
+		routine := p.toks[i+1].text
+
+		// the routine runs until the next define, or the end of its parentheses
+		end := len(p.toks)
+		if p.is(i+3, "(") {
+			var err error
+			if end, err = p.match(i + 3); err != nil {
+				return nil, nil, nil, err
+			}
+		} else {
+			for j := i + 3; j < len(p.toks); j++ {
+				if p.is(j, "define") {
+					end = j
+					break
+				}
+			}
+		}
+
+		p.routines[routine] = sblRoutine{tok: i + 3, end: end, backward: p.isBackward(i)}
+		order = append(order, routine)
+		i = end - 1
+	}
+
+	for _, routine := range order {
+		r := p.routines[routine]
+		if !r.backward {
+			continue
+		}
+
+		for _, tok := range p.toks[r.tok:r.end] {
+			if _, ok := p.routines[tok.text]; ok && tok.kind == 'n' && tok.text != routine {
+				p.called[tok.text] = true
+			}
+		}
+	}
+
+	var (
+		specs []*spec
+		names []string
+		funcs []string
+	)
+
+	for _, routine := range order {
+		var (
+			r         = p.routines[routine]
+			n         = 0
+			substring = false
+		)
+
+		for j := r.tok; j < r.end; j++ {
+			// [substring] conditions among ( ... )
+			if !p.is(j, "[") || !p.is(j+1, "substring") || !p.is(j+2, "]") {
+				continue
+			}
+
+			substring = true
+
+			k := j + 3
+			for k < r.end && !p.is(k, "among") {
+				k++
+			}
+
+			if !p.is(k+1, "(") {
+				return nil, nil, nil, p.errorf(j, "[substring] in %s isn't followed by among", routine)
+			}
+
+			closing, err := p.match(k + 1)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			// atlimit matches whole words rather than suffixes
+			region, atlimit := "", false
+			for _, tok := range p.toks[j+3 : k] {
+				if r, ok := p.regions[tok.text]; ok {
+					region = max(region, r)
+				} else if tok.text == "atlimit" && tok.kind == 'n' {
+					atlimit = true
+				} else {
+					return nil, nil, nil, p.errorf(j, "unsupported condition %s before among in %s", tok.text, routine)
+				}
+			}
+
+			if atlimit {
+				j = closing
+				continue
+			}
+
+			if !r.backward {
+				return nil, nil, nil, p.errorf(j, "%s isn't in backwardmode, so its among doesn't search for suffixes", routine)
+			}
+
+			name := strings.ToLower(strings.ReplaceAll(routine, "_", ""))
+			if n++; n > 1 {
+				name += strconv.Itoa(n)
+			}
+
+			name, ok := p.name(name)
+			if !ok {
+				j = closing
+				continue
+			}
+
+			sp, err := p.among(k+2, closing, routine, region, name)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			specs, names = append(specs, sp), append(names, name)
+			j = closing
+		}
+
+		switch {
+		case substring:
+
+		// Step_1c
+		case r.backward && p.regions[routine] == "" && !p.called[routine]:
+			name, ok := p.name(strings.ToLower(strings.ReplaceAll(routine, "_", "")))
+			if !ok {
+				continue
+			}
+
+			if err := p.compileStep(routine, name+p.tag); err != nil {
+				return nil, nil, nil, fmt.Errorf("%v, or skip %s with -rename", err, name)
+			}
+			funcs = append(funcs, name)
+
+		// mark_regions
+		case !r.backward && p.setsRegions(r):
+			name, ok := p.name(goName(routine))
+			if !ok {
+				continue
+			}
+
+			if err := p.compileRegions(routine, name+p.tag); err != nil {
+				return nil, nil, nil, fmt.Errorf("%v, or skip %s with -rename", err, name)
+			}
+			funcs = append(funcs, name)
+		}
+	}
+
+	return specs, names, funcs, nil
+}
+
+// name returns the name of a generated function, as changed by rename, and
+// false if it's skipped.
+func (p *sblParser) name(name string) (string, bool) {
+	to, ok := p.rename[name]
+	if !ok {
+		return name, true
+	}
+
+	p.renamed[name] = true
+	return to, to != "-"
+}
+
+// setsRegions tells whether the routine r sets the regions, i.e., the first
+// two integers, with setmark or $.
+func (p *sblParser) setsRegions(r sblRoutine) bool {
+	for j := r.tok; j+1 < r.end; j++ {
+		if p.is(j, "setmark") || p.is(j, "$") && p.is(j+2, "=") {
+			for _, name := range p.ints[:min(len(p.ints), 2)] {
+				if p.is(j+1, name) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// isBackward tells whether the i'th token is in a backwardmode.
+func (p *sblParser) isBackward(i int) bool {
+	for _, b := range p.backward {
+		if b[0] < i && i < b[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// among returns the spec for the among whose entries are the tokens from
+// start to end, which is in routine, and has the region of its conditions.
+// The actions that don't fit in the spec are compiled into functions named
+// after its step function, e.g., step1aAction2 for the second action of step1a.
+func (p *sblParser) among(start, end int, routine, region, name string) (*spec, error) {
+	var (
+		lines   []string
+		pending []string // suffixes waiting for their action
+		n       int      // the number of actions
+	)
+
+	lines = append(lines, fmt.Sprintf("# Search for the longest among the suffixes of %s in %s, and perform the action indicated.", routine, p.base))
+
+	flush := func(action string) {
+		for _, suffix := range pending {
+			lines = append(lines, suffix+"\t"+action)
+		}
+		pending = pending[:0]
+	}
+
+	for i := start; i < end; i++ {
+		tok := p.toks[i]
+
+		switch {
+		case tok.kind == 's':
+			if tok.text == "" {
+				return nil, p.errorf(i, "the empty suffix isn't supported in %s", routine)
+			}
+			pending = append(pending, tok.text)
+
+		case p.is(i, "("):
+			closing, err := p.match(i)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(pending) == 0 {
+				return nil, p.errorf(i, "action without a suffix in %s", routine)
+			}
+
+			n++
+			action, err := p.action(i, closing, region, fmt.Sprintf("%sAction%d%s", name, n, p.tag), routine, pending)
+			if err != nil {
+				return nil, err
+			}
+
+			flush(action)
+			i = closing
+
+		case tok.kind == 'n':
+			return nil, p.errorf(i, "routine %s after a suffix in the among of %s isn't supported", tok.text, routine)
+
+		default:
+			return nil, p.errorf(i, "unexpected %s in the among of %s", tok.text, routine)
+		}
+	}
+
+	flush("=")
+
+	sp, err := parseSpec(bufio.NewScanner(strings.NewReader(strings.Join(lines, "\n"))))
+	if err != nil {
+		return nil, p.errorf(start, "%s: %v", routine, err)
+	}
+
+	return sp, nil
+}
+
+// action returns the replacement and conditions of the spec for the action
+// between the tokens open and closing, exclusive, of the suffixes of an among
+// in routine, whose region is region. An action that doesn't fit in a spec is
+// compiled into the function fn, which the spec calls with do:. A suffixfsm:
+// directive on any line of the action takes its place.
+func (p *sblParser) action(open, closing int, region, fn, routine string, suffixes []string) (string, error) {
+	for line := p.toks[open].line; line <= p.toks[closing].line; line++ {
+		if d, ok := p.directives[line]; ok {
+			return d, nil
+		}
+	}
+
+	if action, ok := p.specAction(open, closing, region); ok {
+		return action, nil
+	}
+
+	regions, err := p.compileAction(open, closing, fn, routine, suffixes)
+	if err != nil {
+		return "", fmt.Errorf("%v, or add a suffixfsm: comment with the replacement and conditions of the suffix", err)
+	}
+
+	ru := &rule{keep: true, region: region, do: fn, args: regions}
+	return strings.Join(ru.fields()[1:], "\t"), nil
+}
+
+// specAction is action for the actions that fit in a spec, i.e., the regions,
+// followed by one letter, letters joined by or, or a grouping, which must
+// precede the suffix, and then delete or <-. It returns false for the others.
+func (p *sblParser) specAction(open, closing int, region string) (string, bool) {
+	i := open + 1
+	if i == closing {
+		return "=", true
+	}
+
+	for i < closing && p.toks[i].kind == 'n' && p.regions[p.toks[i].text] != "" {
+		region = max(region, p.regions[p.toks[i].text])
+		i++
+	}
+
+	var after string
+
+	switch {
+	// 's' or 't'
+	case i < closing && p.toks[i].kind == 's':
+		for {
+			if utf8.RuneCountInString(p.toks[i].text) != 1 {
+				return "", false
+			}
+
+			after += p.toks[i].text
+			if i+2 >= closing || !p.is(i+1, "or") || p.toks[i+2].kind != 's' {
+				break
+			}
+			i += 2
+		}
+		i++
+
+	case i < closing && p.toks[i].kind == 'n' && p.groupings[p.toks[i].text] != "":
+		after = p.groupings[p.toks[i].text]
+		i++
+	}
+
+	var repl string
+
+	switch {
+	case p.is(i, "delete"):
+		i++
+	case p.is(i, "<-") && i+1 < closing && p.toks[i+1].kind == 's':
+		repl = p.toks[i+1].text
+		i += 2
+	default:
+		return "", false
+	}
+
+	if i != closing {
+		return "", false
+	}
+
+	ru := &rule{repl: repl, region: region, after: after}
+	return strings.Join(ru.fields()[1:], "\t"), true
+}
+
+// goName returns the Go function for the Snowball routine name, which is the
+// name in camel case starting with a lower case letter, e.g., hasVowel for has_vowel.
+func goName(name string) string {
+	var b strings.Builder
+
+	upper := false
+	for i, r := range name {
+		switch {
+		case r == '_':
+			upper = b.Len() > 0
+		case i == 0:
+			b.WriteRune(unicode.ToLower(r))
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -16,12 +16,31 @@ package main
 
 import (
 	"bufio"
-	"compress/gzip"
+	"bytes"
 	"flag"
 	"fmt"
+	"go/format"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+
+	"github.com/surgebase/porter2/internal/input"
+)
+
+var (
+	name      = flag.String("name", "", "name of the step function, by default the name of the file, e.g., step2 for step2.txt")
+	pkg       = flag.String("pkg", "", "write a complete Go file for this package, with the functions for all the specs")
+	out       = flag.String("o", "", "output file, instead of stdout")
+	outFormat = flag.String("format", "go", "output format: go (the step functions), dot (Graphviz) or mermaid (the state machine diagrams), or spec (the suffix specs, e.g., of a Snowball source)")
+	minimize  = flag.Bool("minimize", false, "merge the equivalent states of the state machines")
+	table     = flag.Bool("table", false, "generate table-driven state machines, with transition arrays indexed by byte, instead of switch statements")
+	tag       = flag.String("tag", "", "append this to the names of the generated functions and tables, e.g., Table for step2Table and suffix2Table")
+	doCheck   = flag.Bool("check", false, "report duplicate and shadowed suffixes and the size of the state machines, instead of generating them")
+	src       = flag.String("src", "", "with -check, compare the cases of the switch f block of each step function in this Go file with the spec")
+	rename    = flag.String("rename", "", "rename the step functions of Snowball sources, e.g., step1a=step0,step1a2=step1a, or skip those written by hand with -, e.g., step5=-")
 )
 
 type node struct {
@@ -30,42 +49,251 @@ type node struct {
 	s int     // state
 	c []*node // children
 	w string  // suffix word
+
+	ws []string // all the suffixes that end in the node, once equivalent nodes are merged
+}
+
+// options change how the step functions and state machines are generated.
+type options struct {
+	minimize bool   // merge equivalent states
+	table    bool   // table-driven state machine, rather than switch statements
+	tag      string // appended to the names of the generated functions and tables
+
+	rename map[string]string // new names of the step functions of Snowball sources, or - to skip them
+}
+
+// rule is one line of a suffix spec, i.e., a suffix and what to do when it's
+// the longest one found.
+type rule struct {
+	suffix string   // the suffix to look for
+	repl   string   // replacement for the suffix, or "" to delete it
+	keep   bool     // leave the word as it is, which still stops shorter suffixes from matching
+	region string   // "r1" or "r2" if the suffix must be in that region, or ""
+	after  string   // letters one of which must precede the suffix, or "" for any
+	cond   string   // function that must return true for the word before the suffix, or ""
+	then   string   // function that finishes the word after the replacement, or ""
+	do     string   // function that performs the whole action instead of the replacement, or ""
+	args   []string // regions that do takes, e.g., r1
+	line   int      // line number in the spec
 }
 
-func openFile(fname string) (*bufio.Scanner, *os.File) {
-	var s *bufio.Scanner
+// spec is a parsed suffix spec file, which is turned into a step function and
+// the state machine it uses.
+type spec struct {
+	doc   []string // comment lines at the top of the file, which document the step function
+	rules []*rule
+	dups  []*rule // rules for suffixes that are already in the spec, which are never reached
+}
 
-	f, err := os.Open(fname)
+// find returns the rule for suffix, or nil if there's none.
+func (sp *spec) find(suffix string) *rule {
+	for _, ru := range sp.rules {
+		if ru.suffix == suffix {
+			return ru
+		}
+	}
+
+	return nil
+}
+
+// loadSpec reads and parses the spec in fname, which is decompressed if it
+// ends in .gz, and returns it along with the name of its step function, which
+// is the name of the file, e.g., step2 for step2.txt.
+func loadSpec(fname string) (*spec, string, error) {
+	return readSpec(fname, parseSpec)
+}
+
+// loadSpecs returns the specs in fname along with the names of their step
+// functions, and the Go code of the functions that go with them. That's the one
+// spec of a suffix spec, with no code, or the spec of each among of a Snowball
+// source, which is a file ending in .sbl, named and compiled as in loadSnowball.
+func loadSpecs(fname string, opt options) ([]*spec, []string, []byte, error) {
+	if filepath.Ext(fname) == ".sbl" {
+		return loadSnowball(fname, opt)
+	}
+
+	sp, name, err := loadSpec(fname)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, nil, err
 	}
 
-	if strings.HasSuffix(fname, ".gz") {
-		gunzip, err := gzip.NewReader(f)
-		if err != nil {
-			log.Fatal(err)
+	return []*spec{sp}, []string{name}, nil, nil
+}
+
+// readSpec is loadSpec with the parser to use, which is scanSpec to keep the
+// suffixes that are already in the spec rather than fail on them.
+func readSpec(fname string, parse func(*bufio.Scanner) (*spec, error)) (*spec, string, error) {
+	r, err := input.Open(fname)
+	if err != nil {
+		return nil, "", err
+	}
+	defer r.Close()
+
+	sp, err := parse(bufio.NewScanner(r))
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %v", fname, err)
+	}
+
+	return sp, specName(fname), nil
+}
+
+// specName returns the name of the step function for the spec in fname, which
+// is the name of the file, e.g., step2 for step2.txt.
+func specName(fname string) string {
+	name := strings.TrimSuffix(filepath.Base(fname), ".gz")
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// parseSpec reads a suffix spec. Each line is a suffix, optionally followed by
+// its replacement and the conditions under which it's replaced:
+//
+//	suffix [replacement [condition ...]]
+//
+// The replacement is - to delete the suffix, = to leave the word as it is, or
+// the letters that replace the suffix. A suffix on its own is the same as =.
+// The conditions are
+//
+//	R1, R2    the suffix must be in R1 or R2
+//	after:xy  the suffix must be preceded by one of the letters x or y
+//	if:fn     fn(word before the suffix) must return true
+//	then:fn   the word is finished by fn(word, regions...) after the replacement
+//	do:fn(r1) the word is fn(word, m, r1) instead, where m is the length of the
+//	          suffix, and the regions in parentheses are optional
+//
+// A suffix with do: must be left as it is, with =, as fn does the replacing.
+// Lines starting with # are comments, and those before the first suffix become
+// the doc comment of the step function. A suffix that is already in the spec
+// is an error.
+func parseSpec(scan *bufio.Scanner) (*spec, error) {
+	sp, err := scanSpec(scan)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sp.dups) > 0 {
+		ru := sp.dups[0]
+		return nil, fmt.Errorf("line %d: suffix %q is already on line %d", ru.line, ru.suffix, sp.find(ru.suffix).line)
+	}
+
+	return sp, nil
+}
+
+// scanSpec is parseSpec, except that the suffixes that are already in the spec
+// are kept in dups, instead of failing on the first one.
+func scanSpec(scan *bufio.Scanner) (*spec, error) {
+	sp := &spec{}
+	suffixes := make(map[string]bool)
+
+	for n := 1; scan.Scan(); n++ {
+		line := strings.TrimSpace(scan.Text())
+
+		if strings.HasPrefix(line, "#") {
+			if len(sp.rules) == 0 {
+				sp.doc = append(sp.doc, strings.TrimPrefix(strings.TrimPrefix(line, "#"), " "))
+			}
+			continue
 		}
 
-		s = bufio.NewScanner(gunzip)
-	} else {
-		s = bufio.NewScanner(f)
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		r := &rule{suffix: fields[0], keep: true, line: n}
+
+		if len(fields) > 1 {
+			switch repl := fields[1]; repl {
+			case "=":
+			case "-":
+				r.keep = false
+			default:
+				r.keep, r.repl = false, repl
+			}
+		}
+
+		for _, c := range fields[min(len(fields), 2):] {
+			key, val, _ := strings.Cut(c, ":")
+
+			switch {
+			case c == "R1" || c == "R2":
+				r.region = strings.ToLower(c)
+			case key == "after" && val != "":
+				r.after = val
+			case key == "if" && val != "":
+				r.cond = val
+			case key == "then" && val != "":
+				r.then = val
+			case key == "do" && val != "":
+				fn, args, ok := parseDo(val)
+				if !ok {
+					return nil, fmt.Errorf("line %d: bad condition %q, expected do:fn or do:fn(r1,r2)", n, c)
+				}
+				r.do, r.args = fn, args
+			default:
+				return nil, fmt.Errorf("line %d: unknown condition %q", n, c)
+			}
+		}
+
+		if r.keep && r.do == "" && (r.region != "" || r.after != "" || r.cond != "" || r.then != "") {
+			return nil, fmt.Errorf("line %d: suffix %q is left as it is, so it can't have conditions", n, r.suffix)
+		}
+
+		if !r.keep && r.do != "" {
+			return nil, fmt.Errorf("line %d: suffix %q is replaced by do:%s, so it must be left as it is with =", n, r.suffix, r.do)
+		}
+
+		if suffixes[r.suffix] {
+			sp.dups = append(sp.dups, r)
+			continue
+		}
+		suffixes[r.suffix] = true
+
+		sp.rules = append(sp.rules, r)
+	}
+
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(sp.rules) == 0 {
+		return nil, fmt.Errorf("no suffixes found")
 	}
 
-	return s, f
+	return sp, nil
 }
 
-func main() {
-	flag.Parse()
+// parseDo returns the function and regions of a do: condition, e.g., fn and
+// r1 for fn(r1).
+func parseDo(val string) (string, []string, bool) {
+	fn, args, ok := strings.Cut(val, "(")
+	if !ok {
+		return fn, nil, true
+	}
+
+	args, ok = strings.CutSuffix(args, ")")
+	if !ok || fn == "" {
+		return "", nil, false
+	}
 
-	scan, file := openFile(flag.Arg(0))
-	defer file.Close()
+	regions := strings.Split(args, ",")
+	for i, r := range regions {
+		if r != "r1" && r != "r2" || i > 0 && r <= regions[i-1] {
+			return "", nil, false
+		}
+	}
+
+	return fn, regions, true
+}
 
+// tree builds the suffix tree for the rules, and returns its nodes in the order
+// they were created, which is also the order of their states. The root is state 0.
+func (sp *spec) tree() []*node {
 	s := 0 // state
 	root := &node{s: s}
 	nodes := append(make([]*node, 0, 10), root)
 
-	for scan.Scan() {
-		w := scan.Text()
+	for _, ru := range sp.rules {
+		w := ru.suffix
 		rs := []rune(w)
 		cur := root
 
@@ -92,16 +320,298 @@ func main() {
 			if i == 0 {
 				n.f = true
 				n.w = w
+				n.ws = []string{w}
 			}
 		}
 	}
 
-	fmt.Println(`var (
+	return nodes
+}
+
+// write writes sp in the format parseSpec reads.
+func (sp *spec) write(w io.Writer) error {
+	var b strings.Builder
+
+	for _, line := range sp.doc {
+		if line == "" {
+			b.WriteString("#\n")
+		} else {
+			fmt.Fprintf(&b, "# %s\n", line)
+		}
+	}
+
+	for _, ru := range sp.rules {
+		b.WriteString(strings.Join(ru.fields(), "\t") + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// fields returns the fields of ru's line in a spec.
+func (ru *rule) fields() []string {
+	fields := []string{ru.suffix}
+
+	switch {
+	case ru.keep:
+		fields = append(fields, "=")
+	case ru.repl == "":
+		fields = append(fields, "-")
+	default:
+		fields = append(fields, ru.repl)
+	}
+
+	if ru.region != "" {
+		fields = append(fields, strings.ToUpper(ru.region))
+	}
+	if ru.after != "" {
+		fields = append(fields, "after:"+ru.after)
+	}
+	if ru.cond != "" {
+		fields = append(fields, "if:"+ru.cond)
+	}
+	if ru.then != "" {
+		fields = append(fields, "then:"+ru.then)
+	}
+	if ru.do != "" && len(ru.args) > 0 {
+		fields = append(fields, fmt.Sprintf("do:%s(%s)", ru.do, strings.Join(ru.args, ",")))
+	} else if ru.do != "" {
+		fields = append(fields, "do:"+ru.do)
+	}
+
+	return fields
+}
+
+// regions returns the region parameters the step function takes, i.e., those
+// that any of the rules refer to, or pass to their do: function.
+func (sp *spec) regions() []string {
+	var r1, r2 bool
+
+	for _, ru := range sp.rules {
+		r1 = r1 || ru.region == "r1" || slices.Contains(ru.args, "r1")
+		r2 = r2 || ru.region == "r2" || slices.Contains(ru.args, "r2")
+	}
+
+	var regions []string
+	if r1 {
+		regions = append(regions, "r1")
+	}
+	if r2 {
+		regions = append(regions, "r2")
+	}
+
+	return regions
+}
+
+// body returns the code for the case of the step function's switch f block
+// that handles ru, and whether it uses the suffix length m.
+func (ru *rule) body(regions []string) (string, bool) {
+	if ru.keep && ru.do == "" {
+		return "// do nothing\n", false
+	}
+
+	var (
+		b     bytes.Buffer
+		conds []string
+		m     bool
+	)
+
+	// the action, which keeps the letters the suffix and replacement share
+	same := 0
+	for same < len(ru.suffix) && same < len(ru.repl) && ru.suffix[same] == ru.repl[same] {
+		same++
+	}
+
+	var action string
+	switch cut, add := len(ru.suffix)-same, ru.repl[same:]; {
+	case ru.do != "":
+		action, m = fmt.Sprintf("rs = %s(%s)\n", ru.do, strings.Join(append([]string{"rs", "m"}, ru.args...), ", ")), true
+	case same == 0 && add == "":
+		action, m = "rs = rs[:l-m]\n", true
+	case add == "":
+		action = fmt.Sprintf("rs = rs[:l-%d]\n", cut)
+	default:
+		var letters []string
+		for _, r := range add {
+			letters = append(letters, fmt.Sprintf("%q", r))
+		}
+		action = fmt.Sprintf("rs = append(rs[:l-%d], %s)\n", cut, strings.Join(letters, ", "))
+	}
+
+	if ru.then != "" {
+		action += fmt.Sprintf("rs = %s(%s)\n", ru.then, strings.Join(append([]string{"rs"}, regions...), ", "))
+	}
+
+	if ru.region != "" {
+		conds, m = append(conds, fmt.Sprintf("l-m >= %s", ru.region)), true
+	}
+
+	if ru.after != "" {
+		conds, m = append(conds, "l > m"), true
+	}
+
+	if ru.cond != "" {
+		conds, m = append(conds, fmt.Sprintf("%s(rs[:l-m])", ru.cond)), true
+	}
+
+	if len(conds) > 0 {
+		fmt.Fprintf(&b, "if %s {\n", strings.Join(conds, " && "))
+	}
+
+	if ru.after != "" {
+		var letters []string
+		for _, r := range ru.after {
+			letters = append(letters, fmt.Sprintf("%q", r))
+		}
+		fmt.Fprintf(&b, "switch rs[l-m-1] {\ncase %s:\n", strings.Join(letters, ", "))
+	}
+
+	b.WriteString(action)
+
+	if ru.after != "" {
+		b.WriteString("}\n")
+	}
+
+	if len(conds) > 0 {
+		b.WriteString("}\n")
+	}
+
+	return b.String(), m
+}
+
+// automaton returns the states of the state machine for sp, which are the
+// nodes of its suffix tree, or the merged nodes if minimize is true.
+func (sp *spec) automaton(minimize bool) []*node {
+	nodes := sp.tree()
+	if minimize {
+		nodes = sp.minimize(nodes)
+	}
+
+	return nodes
+}
+
+// generate writes the step function called name for sp, followed by the state
+// machine it uses, which is called suffix instead of step, e.g., suffix2 for step2.
+func (sp *spec) generate(w io.Writer, name string, opt options) error {
+	var (
+		b       bytes.Buffer
+		nodes   = sp.automaton(opt.minimize)
+		regions = sp.regions()
+		fsm     = "suffix" + strings.TrimPrefix(name, "step") + opt.tag
+	)
+
+	name += opt.tag
+
+	// rules by their suffix
+	rules := make(map[string]*rule)
+	for _, ru := range sp.rules {
+		rules[ru.suffix] = ru
+	}
+
+	// rules with the same body share a case, in the order of their first state
+	var (
+		bodies []string
+		states = make(map[string][]*node)
+		useM   bool
+	)
+
+	for _, n := range nodes {
+		if !n.f {
+			continue
+		}
+
+		body, m := rules[n.w].body(regions)
+		if _, ok := states[body]; !ok {
+			bodies = append(bodies, body)
+		}
+
+		states[body] = append(states[body], n)
+		useM = useM || m
+	}
+
+	if len(sp.doc) > 0 {
+		for _, line := range sp.doc {
+			if line == "" {
+				b.WriteString("//\n")
+			} else {
+				fmt.Fprintf(&b, "// %s\n", line)
+			}
+		}
+	} else {
+		fmt.Fprintf(&b, "// %s searches for the longest suffix, and performs the action indicated.\n", name)
+	}
+
+	params := "rs []T"
+	if len(regions) > 0 {
+		params += ", " + strings.Join(regions, ", ") + " int"
+	}
+
+	fmt.Fprintf(&b, "func %s[T letter](%s) []T {\n", name, params)
+
+	if useM {
+		fmt.Fprintf(&b, "l := len(rs)\nm, f := %s(rs)\n\nswitch f {\n", fsm)
+	} else {
+		fmt.Fprintf(&b, "l := len(rs)\n_, f := %s(rs)\n\nswitch f {\n", fsm)
+	}
+
+	for i, body := range bodies {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		var cases []string
+		for _, n := range states[body] {
+			cases = append(cases, fmt.Sprint(n.s))
+		}
+
+		fmt.Fprintf(&b, "case %s:\n", strings.Join(cases, ", "))
+
+		for _, n := range states[body] {
+			for _, w := range n.ws {
+				fmt.Fprintf(&b, "// %s - final\n", w)
+			}
+		}
+
+		b.WriteString(body)
+	}
+
+	b.WriteString("}\n\nreturn rs\n}\n\n")
+
+	if opt.table {
+		if err := writeTable(&b, nodes, fsm, name); err != nil {
+			return err
+		}
+	} else {
+		writeSwitch(&b, nodes, fsm, name, opt.minimize)
+	}
+
+	// gofmt the functions as part of a file, which is the only way their doc
+	// comments get formatted the same as in the package they're pasted into
+	const pkg = "package p\n\n"
+
+	src, err := format.Source(append([]byte(pkg), b.Bytes()...))
+	if err != nil {
+		return fmt.Errorf("formatting %s: %v\n%s", name, err, b.Bytes())
+	}
+
+	_, err = w.Write(bytes.TrimPrefix(src, []byte(pkg)))
+	return err
+}
+
+// writeSwitch writes the state machine fsm used by the step function name as
+// nested switch statements, one case for each state with children. The states
+// of a minimized state machine are shared by suffixes of different lengths, so
+// the suffix length is where the final state is reached rather than its suffix.
+func writeSwitch(b *bytes.Buffer, nodes []*node, fsm, name string, minimize bool) {
+	fmt.Fprintf(b, `// %s runs the state machine for %s over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func %s[T letter](rs []T) (int, int) {
+var (
 		l int = len(rs) // string length
 		m int			// suffix length
 		s int			// state
 		f int			// end state of longgest suffix
-		r rune			// current rune
+		r T			// current rune
 	)
 
 loop:
@@ -109,41 +619,241 @@ loop:
 		r = rs[l-i-1]
 
 		switch s {
-`)
+
+`, fsm, name, fsm)
 
 	for _, n := range nodes {
 		if len(n.c) > 0 {
-			fmt.Printf("case %d:\n", n.s)
-			fmt.Printf("\tswitch r {\n")
+			fmt.Fprintf(b, "case %d:\n", n.s)
+			fmt.Fprintf(b, "\tswitch r {\n")
 
 			for _, c := range n.c {
-				fmt.Printf("\tcase '%c':\n", c.r)
-				fmt.Printf("\t\ts = %d\n", c.s)
+				fmt.Fprintf(b, "\tcase %q:\n", c.r)
+				fmt.Fprintf(b, "\t\ts = %d\n", c.s)
 				if c.f {
-					fmt.Printf("\t\tm = %d\n", len(c.w))
-					fmt.Printf("\t\tf = %d\n", c.s)
-					fmt.Printf("\t\t// %s - final\n", c.w)
+					if minimize {
+						fmt.Fprintf(b, "\t\tm = i + 1\n")
+					} else {
+						fmt.Fprintf(b, "\t\tm = %d\n", len(c.w))
+					}
+					fmt.Fprintf(b, "\t\tf = %d\n", c.s)
+					for _, w := range c.ws {
+						fmt.Fprintf(b, "\t\t// %s - final\n", w)
+					}
 				}
 			}
 
-			fmt.Printf("\tdefault:\n\t\tbreak loop\n\t}\n")
+			fmt.Fprintf(b, "\tdefault:\n\t\tbreak loop\n\t}\n")
 		}
 	}
 
-	fmt.Printf(`default:
-			break loop
+	b.WriteString("default:\nbreak loop\n}\n}\n\nreturn m, f\n}\n")
+}
+
+// generateFile writes a Go source file for package pkg, with the functions
+// generated from each of the specs in fnames.
+func generateFile(w io.Writer, pkg string, fnames []string, opt options) error {
+	var (
+		b     bytes.Buffer
+		bases []string
+	)
+
+	for _, fname := range fnames {
+		bases = append(bases, filepath.Base(fname))
+	}
+
+	fmt.Fprintf(&b, "// Code generated by suffixfsm from %s. DO NOT EDIT.\n\n", strings.Join(bases, ", "))
+	fmt.Fprintf(&b, "package %s\n", pkg)
+
+	for _, fname := range fnames {
+		specs, names, code, err := loadSpecs(fname, opt)
+		if err != nil {
+			return err
+		}
+
+		for i, sp := range specs {
+			b.WriteString("\n")
+
+			if err := sp.generate(&b, names[i], opt); err != nil {
+				return err
+			}
+		}
+
+		if len(code) > 0 {
+			b.WriteString("\n")
+			b.Write(code)
 		}
 	}
 
-	switch f {
-`)
+	_, err := w.Write(b.Bytes())
+	return err
+}
 
-	for _, n := range nodes {
-		if n.f {
-			fmt.Printf("\tcase %d:\n", n.s)
-			fmt.Printf("\t\t// %s - final\n\n", n.w)
+// expand returns the files matched by the patterns, in order.
+func expand(patterns []string) ([]string, error) {
+	var fnames []string
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%s: no such file", pattern)
+		}
+
+		fnames = append(fnames, matches...)
+	}
+
+	return fnames, nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: suffixfsm [flags] spec ...\n\n")
+	fmt.Fprintf(os.Stderr, "Generates the step function and state machine for each suffix spec. Specs can be glob patterns, e.g., 'step*.txt'.\n\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+
+	flag.Usage = usage
+	flag.Parse()
+
+	fnames, err := expand(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(fnames) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var (
+		b   bytes.Buffer
+		opt = options{minimize: *minimize, table: *table, tag: *tag}
+	)
+
+	if *rename != "" {
+		opt.rename = make(map[string]string)
+
+		for _, r := range strings.Split(*rename, ",") {
+			from, to, ok := strings.Cut(r, "=")
+			if !ok || from == "" || to == "" {
+				log.Fatalf("bad -rename %q, expected name=new or name=-", r)
+			}
+
+			opt.rename[from] = to
+		}
+	}
+
+	switch {
+	case *outFormat != "go" && *outFormat != "dot" && *outFormat != "mermaid" && *outFormat != "spec":
+		log.Fatalf("unknown format %q", *outFormat)
+
+	case *doCheck:
+		if *pkg != "" || *outFormat != "go" {
+			log.Fatal("-pkg and -format can't be used with -check")
+		}
+
+		if *name != "" && len(fnames) > 1 {
+			log.Fatal("-name can only be used with a single spec")
+		}
+
+		problems := 0
+		for _, fname := range fnames {
+			fn := *name
+			if fn == "" {
+				fn = specName(fname)
+			}
+
+			n, err := check(&b, fname, fn+opt.tag, *src, opt)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			problems += n
+		}
+
+		os.Stdout.Write(b.Bytes())
+
+		if problems > 0 {
+			log.Fatalf("%d problems found", problems)
+		}
+		return
+
+	case *pkg != "":
+		if *name != "" || *outFormat != "go" {
+			log.Fatal("-name and -format can't be used with -pkg")
+		}
+
+		err = generateFile(&b, *pkg, fnames, opt)
+
+	default:
+		var (
+			specs []*spec
+			names []string
+			code  []byte
+		)
+
+		for _, fname := range fnames {
+			sps, fns, c, err := loadSpecs(fname, opt)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			specs, names = append(specs, sps...), append(names, fns...)
+			if len(c) > 0 {
+				code = append(append(code, '\n'), c...)
+			}
+		}
+
+		if *name != "" {
+			if len(specs) > 1 {
+				log.Fatal("-name can only be used with a single spec")
+			}
+
+			names[0] = *name
+		}
+
+		for i, sp := range specs {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+
+			switch *outFormat {
+			case "dot":
+				err = sp.dot(&b, names[i], opt.minimize)
+			case "mermaid":
+				err = sp.mermaid(&b, names[i], opt.minimize)
+			case "spec":
+				err = sp.write(&b)
+			default:
+				err = sp.generate(&b, names[i], opt)
+			}
+
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		if *outFormat == "go" {
+			b.Write(code)
 		}
 	}
 
-	fmt.Printf("\t}\n\treturn rs\n")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(b.Bytes())
+		return
+	}
+
+	if err := os.WriteFile(*out, b.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
 }
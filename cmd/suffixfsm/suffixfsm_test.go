@@ -0,0 +1,515 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// generateDirective matches the go:generate directives that run suffixfsm.
+var generateDirective = regexp.MustCompile(`(?m)^//go:generate go run \S+/cmd/suffixfsm((?: -minimize| -table| -tag \w+)*) -pkg (\w+) -o (\S+) (.+)$`)
+
+// TestGeneratedUpToDate checks that the generated state machines of each package
+// match their specs, by running the same command as each of the package's
+// go:generate directives, and comparing the result with the checked in file.
+func TestGeneratedUpToDate(t *testing.T) {
+	for _, fname := range []string{"../../porter2.go", "../../porter1/porter1.go"} {
+		dir := filepath.Dir(fname)
+
+		src, err := os.ReadFile(fname)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		ms := generateDirective.FindAllSubmatch(src, -1)
+		if !assert.NotEmpty(t, ms, "%s: no go:generate directive for suffixfsm", fname) {
+			continue
+		}
+
+		for _, m := range ms {
+			var opt options
+			for flags := strings.Fields(string(m[1])); len(flags) > 0; flags = flags[1:] {
+				switch flags[0] {
+				case "-minimize":
+					opt.minimize = true
+				case "-table":
+					opt.table = true
+				case "-tag":
+					opt.tag, flags = flags[1], flags[1:]
+				}
+			}
+
+			var patterns []string
+			for _, pattern := range strings.Fields(string(m[4])) {
+				patterns = append(patterns, filepath.Join(dir, pattern))
+			}
+
+			fnames, err := expand(patterns)
+			if !assert.NoError(t, err) {
+				continue
+			}
+
+			var b bytes.Buffer
+			if !assert.NoError(t, generateFile(&b, string(m[2]), fnames, opt)) {
+				continue
+			}
+
+			generated, err := os.ReadFile(filepath.Join(dir, string(m[3])))
+			if !assert.NoError(t, err) {
+				continue
+			}
+
+			assert.True(t, bytes.Equal(b.Bytes(), generated), "%s is out of date with its specs, run go generate", filepath.Join(dir, string(m[3])))
+		}
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	sp, err := parseSpec(bufio.NewScanner(strings.NewReader(`# Doc comment
+#
+# for the step.
+tional	tion	R1
+li	-	R1	after:cd
+ies	-	then:ie
+s	-	if:hasVowel
+ied	=	do:ie(r2)
+us
+`)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Doc comment", "", "for the step."}, sp.doc)
+	assert.Equal(t, []*rule{
+		{suffix: "tional", repl: "tion", region: "r1", line: 4},
+		{suffix: "li", region: "r1", after: "cd", line: 5},
+		{suffix: "ies", then: "ie", line: 6},
+		{suffix: "s", cond: "hasVowel", line: 7},
+		{suffix: "ied", keep: true, do: "ie", args: []string{"r2"}, line: 8},
+		{suffix: "us", keep: true, line: 9},
+	}, sp.rules)
+	assert.Equal(t, []string{"r1", "r2"}, sp.regions())
+
+	for spec, msg := range map[string]string{
+		"":                 "no suffixes found",
+		"s -\ns =":         `line 2: suffix "s" is already on line 1`,
+		"s - R3":           `line 1: unknown condition "R3"`,
+		"s - after:":       `line 1: unknown condition "after:"`,
+		"s = R1":           `line 1: suffix "s" is left as it is, so it can't have conditions`,
+		"s - do:fn":        `line 1: suffix "s" is replaced by do:fn, so it must be left as it is with =`,
+		"s = do:fn(r3)":    `line 1: bad condition "do:fn(r3)", expected do:fn or do:fn(r1,r2)`,
+		"s = do:fn(r2,r1)": `line 1: bad condition "do:fn(r2,r1)", expected do:fn or do:fn(r1,r2)`,
+		"# only comments":  "no suffixes found",
+	} {
+		_, err := parseSpec(bufio.NewScanner(strings.NewReader(spec)))
+		assert.EqualError(t, err, msg, spec)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	sp, err := parseSpec(bufio.NewScanner(strings.NewReader("ational\tate\tR1\ntional\ttion\tR1\nion\t-\tR2\tafter:st\nied\t=\tdo:ie(r1)\n")))
+	assert.NoError(t, err)
+
+	var b bytes.Buffer
+	assert.NoError(t, sp.generate(&b, "step9", options{}))
+
+	src := b.String()
+	assert.Contains(t, src, "func step9[T letter](rs []T, r1, r2 int) []T {")
+	assert.Contains(t, src, "m, f := suffix9(rs)")
+	assert.Contains(t, src, "func suffix9[T letter](rs []T) (int, int) {")
+	assert.Contains(t, src, "rs = append(rs[:l-5], 'e')")
+	assert.Contains(t, src, "rs = rs[:l-2]")
+	assert.Contains(t, src, "case 's', 't':")
+	assert.Contains(t, src, "rs = ie(rs, m, r1)")
+}
+
+func TestGraphs(t *testing.T) {
+	sp, err := parseSpec(bufio.NewScanner(strings.NewReader("'s\t-\ns\t-\n")))
+	assert.NoError(t, err)
+
+	var b bytes.Buffer
+	assert.NoError(t, sp.dot(&b, "step0", false))
+	assert.Equal(t, `digraph "step0" {
+	rankdir=LR;
+	node [shape=circle];
+	0 [label="0"];
+	1 [shape=doublecircle, label="1\ns"];
+	2 [shape=doublecircle, label="2\n's"];
+	0 -> 1 [label="s"];
+	1 -> 2 [label="'"];
+}
+`, b.String())
+
+	b.Reset()
+	assert.NoError(t, sp.mermaid(&b, "step0", false))
+	assert.Equal(t, `---
+title: step0
+---
+flowchart LR
+	s0(("0"))
+	s1((("1<br/>s")))
+	s2((("2<br/>'s")))
+	s0 -->|"s"| s1
+	s1 -->|"'"| s2
+`, b.String())
+}
+
+func TestMinimize(t *testing.T) {
+	sp, err := parseSpec(bufio.NewScanner(strings.NewReader("ied\t-\tthen:ie\nies\t-\tthen:ie\nus\t=\nis\t=\nsses\tss\n")))
+	assert.NoError(t, err)
+	assert.Len(t, sp.tree(), 11)
+
+	// ied and ies end in the same state, and so do us and is, but not sses,
+	// which has a different action
+	nodes := sp.automaton(true)
+	assert.Len(t, nodes, 9)
+
+	var finals [][]string
+	for _, n := range nodes {
+		if n.f {
+			finals = append(finals, n.ws)
+		}
+	}
+	assert.Equal(t, [][]string{{"ied", "ies"}, {"us", "is"}, {"sses"}}, finals)
+
+	var b bytes.Buffer
+	assert.NoError(t, sp.generate(&b, "step9", options{minimize: true}))
+
+	src := b.String()
+	assert.Contains(t, src, "// ied - final\n\t\t// ies - final\n\t\trs = rs[:l-m]\n\t\trs = ie(rs)\n")
+	assert.Contains(t, src, "m = i + 1\n")
+	assert.NotContains(t, src, "m = 3\n")
+}
+
+func TestMinimizeGraphs(t *testing.T) {
+	sp, err := parseSpec(bufio.NewScanner(strings.NewReader("ied\t-\tthen:ie\nies\t-\tthen:ie\nus\t=\nis\t=\nsses\tss\n")))
+	assert.NoError(t, err)
+
+	var tree, min bytes.Buffer
+	assert.NoError(t, sp.dot(&tree, "step9", false))
+	assert.NoError(t, sp.dot(&min, "step9", true))
+
+	// the 11 nodes of the tree collapse into 9 states, and the shared final
+	// states are labelled with both of their suffixes
+	states := regexp.MustCompile(`(?m)^\t\d+ \[`)
+	assert.Len(t, states.FindAllString(tree.String(), -1), 11)
+	assert.Len(t, states.FindAllString(min.String(), -1), 9)
+	assert.Contains(t, min.String(), `3 [shape=doublecircle, label="3\nied, ies"];`)
+	assert.Contains(t, min.String(), `6 [shape=doublecircle, label="6\nus, is"];`)
+	assert.Contains(t, min.String(), `4 -> 6 [label="u"];`)
+	assert.Contains(t, min.String(), `4 -> 6 [label="i"];`)
+
+	min.Reset()
+	assert.NoError(t, sp.mermaid(&min, "step9", true))
+	assert.Contains(t, min.String(), `s3((("3<br/>ied, ies")))`)
+	assert.Contains(t, min.String(), `s5 -->|"i"| s3`)
+}
+
+func TestGenerateTable(t *testing.T) {
+	sp, err := parseSpec(bufio.NewScanner(strings.NewReader("'s\t-\ns\t-\n")))
+	assert.NoError(t, err)
+
+	var b bytes.Buffer
+	assert.NoError(t, sp.generate(&b, "step0", options{table: true, tag: "Table"}))
+
+	src := b.String()
+	assert.Contains(t, src, "func step0Table[T letter](rs []T) []T {")
+	assert.Contains(t, src, "m, f := suffix0Table(rs)")
+	assert.Contains(t, src, "func suffix0Table[T letter](rs []T) (int, int) {")
+	assert.Contains(t, src, "var suffix0TableClass = [256]uint8{\n\t'\\'': 1,\n\t's':  2,\n}")
+	assert.Contains(t, src, "var suffix0TableNext = [3][3]uint8{\n\t{0, 0, 1}, // 0\n\t{0, 2, 0}, // 1\n\t{0, 0, 0}, // 2\n}")
+	assert.Contains(t, src, "var suffix0TableFinal = [3]bool{\n\t1: true, // s\n\t2: true, // 's\n}")
+
+	sp, err = parseSpec(bufio.NewScanner(strings.NewReader("’s\t-\n")))
+	assert.NoError(t, err)
+	assert.EqualError(t, sp.generate(&b, "step0", options{table: true}), `step0: letter '’' doesn't fit in a byte, so it can't be in a table`)
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	fname := filepath.Join(dir, "step9.txt")
+	assert.NoError(t, os.WriteFile(fname, []byte("ies\ti\ns\t-\nss\t=\ns\t=\n"), 0644))
+
+	src := filepath.Join(dir, "step9.go")
+	assert.NoError(t, os.WriteFile(src, []byte(`package p
+
+func step9(rs []rune) []rune {
+	_, f := suffix9(rs)
+
+	switch f {
+	case 3:
+		// ies - final
+	case 4:
+		// s - final
+	case 2:
+		// do nothing
+	}
+
+	return rs
+}
+`), 0644))
+
+	var b bytes.Buffer
+	problems, err := check(&b, fname, "step9", src, options{})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, problems)
+	assert.Equal(t, fname+`:4: suffix "s" is already on line 2, so it's never reached
+`+fname+`:2: suffix "s" is shadowed by ies, ss
+`+fname+`: 3 suffixes, 5 states, 3 final, maximum depth 3
+`+src+`:9:2: case 4 of step9 is for suffix "ss" in `+fname+`, which isn't in its comments
+`+src+`:11:2: case 2 of step9 isn't a final state in `+fname+`
+`+src+`: step9 has no case for final state 1, suffix s
+`, b.String())
+
+	_, err = check(&b, fname, "step8", src, options{})
+	assert.EqualError(t, err, src+": no function step8")
+}
+
+// TestCheckGenerated runs -check over the specs of each package against its
+// generated step functions, as in the README.
+func TestCheckGenerated(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, src string
+		opt          options
+	}{
+		{"step*.txt", "../../steps_gen.go", options{}},
+		{"step*.txt", "../../steps_table_gen_test.go", options{minimize: true, tag: "Table"}},
+		{"porter1/step*.txt", "../../porter1/steps_gen.go", options{}},
+	} {
+		fnames, err := expand([]string{tt.pattern})
+		if !assert.NoError(t, err) || !assert.NotEmpty(t, fnames, tt.pattern) {
+			continue
+		}
+
+		for _, fname := range fnames {
+			var b bytes.Buffer
+			problems, err := check(&b, fname, specName(fname)+tt.opt.tag, tt.src, tt.opt)
+			assert.NoError(t, err, fname)
+			assert.Zero(t, problems, "%s against %s:\n%s", fname, tt.src, b.String())
+		}
+	}
+}
+
+// englishRename maps the steps of the Snowball english stemmer to those of
+// porter2, where step0 is the first among of Step_1a, and step1c and step5 are
+// written by hand.
+var englishRename = map[string]string{"step1a": "step0", "step1a2": "step1a", "step1c": "-", "step5": "-"}
+
+func TestSnowball(t *testing.T) {
+	specs, names, code, err := loadSnowball("testdata/english.sbl", options{rename: englishRename})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"step0", "step1a", "step1b", "step2", "step3", "step4"}, names)
+
+	// the suffixes are in a different order, but do the same as in the specs
+	// written by hand, except for the actions that are compiled rather than
+	// done by functions written by hand
+	bySuffix := func(sp *spec) map[string]rule {
+		rules := make(map[string]rule)
+		for _, ru := range sp.rules {
+			r := *ru
+			r.line = 0
+			rules[r.suffix] = r
+		}
+		return rules
+	}
+
+	compiled := map[string][]rule{
+		"step1a": {
+			{suffix: "ied", keep: true, do: "step1aAction2"},
+			{suffix: "ies", keep: true, do: "step1aAction2"},
+			{suffix: "s", keep: true, do: "step1aAction3"},
+		},
+		"step1b": {
+			{suffix: "ed", keep: true, do: "step1bAction2", args: []string{"r1"}},
+			{suffix: "edly", keep: true, do: "step1bAction2", args: []string{"r1"}},
+			{suffix: "ing", keep: true, do: "step1bAction2", args: []string{"r1"}},
+			{suffix: "ingly", keep: true, do: "step1bAction2", args: []string{"r1"}},
+		},
+	}
+
+	for i, name := range names {
+		sp, _, err := loadSpec(name + ".txt")
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		want := bySuffix(sp)
+		for _, ru := range compiled[name] {
+			want[ru.suffix] = ru
+		}
+
+		assert.Equal(t, want, bySuffix(specs[i]), name)
+	}
+
+	// the actions, the routines they call and mark_regions are compiled, but
+	// not the steps that are written by hand
+	for _, fn := range []string{"step1aAction2", "step1aAction3", "step1bAction2", "sblShortv", "markRegions"} {
+		assert.Contains(t, string(code), "\nfunc "+fn+"[T letter](", fn)
+	}
+	assert.NotContains(t, string(code), "func step1c[")
+	assert.NotContains(t, string(code), "func step5Action1[")
+
+	// without the renames, all of the steps are compiled
+	_, names, code, err = loadSnowball("testdata/english.sbl", options{tag: "Sbl"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"step1a", "step1a2", "step1b", "step2", "step3", "step4", "step5"}, names)
+	assert.Contains(t, string(code), "\nfunc step1cSbl[T letter](rs []T) []T {")
+	assert.Contains(t, string(code), "\nfunc step5Action1Sbl[T letter](rs []T, m, r1, r2 int) []T {")
+	assert.Contains(t, string(code), "\nfunc markRegionsSbl[T letter](rs []T) (r1, r2 int) {")
+	assert.Contains(t, string(code), "\ntype sblEnvSbl[T letter] struct {")
+
+	_, _, _, err = loadSnowball("testdata/english.sbl", options{rename: map[string]string{"step1a": "step0", "step6": "-"}})
+	assert.EqualError(t, err, "testdata/english.sbl: no step step6 to rename")
+
+	_, _, _, err = loadSnowball("testdata/english.sbl", options{rename: map[string]string{"step1a": "step2"}})
+	assert.EqualError(t, err, "testdata/english.sbl: more than one step is called step2")
+
+	_, _, _, err = loadSnowball("testdata/english.sbl", options{rename: map[string]string{"markRegions": "step3"}})
+	assert.EqualError(t, err, "testdata/english.sbl: more than one step is called step3")
+
+	const directive = ", or add a suffixfsm: comment with the replacement and conditions of the suffix"
+
+	for src, msg := range map[string]string{
+		"define Step_1 as ( [substring] among ( 's' (next delete) ) )":                   "",
+		"define Step_1 as ( [substring] among ( 's' (setlimit tomark p1 for delete) ) )": "x.sbl:1: unsupported command setlimit" + directive,
+		"define Step_1 as ( [substring] among ( 's' (setlimit) // suffixfsm: -\n) )":     "",
+		"define Step_1 as ( [substring] among ( 's' ('ab' delete) ) )":                   "",
+		"define Step_1 as ( [substring] among ( 's' (atmark p1 delete) ) )":              "x.sbl:1: integer p1 isn't one of the first two, which are the regions" + directive,
+		"define Step_1 as ( [substring] among ( 's' (R) ) ) define R as ( next R )":      "x.sbl:1: R calls itself, which isn't supported" + directive,
+		"define Step_1 as ( [substring] among ( 's' R ) ) define R as ( next )":          "x.sbl:1: routine R after a suffix in the among of Step_1 isn't supported",
+		"define Step_1 as ( [substring] R3 among ( 's' (delete) ) )":                     "x.sbl:1: unsupported condition R3 before among in Step_1",
+		"define Step_1 as ( [substring] among ( 's' '' (delete) ) )":                     "x.sbl:1: the empty suffix isn't supported in Step_1",
+		"define Step_1 as ( [substring] among ( 's' (delete) 's' ) )":                    `x.sbl:1: Step_1: line 3: suffix "s" is already on line 2`,
+		"define Step_1 as ( among ( 's' (delete) ) )":                                    "",
+		"define Step_1 as ( booleans )":                                                  "x.sbl:1: unsupported command booleans, or skip step1 with -rename",
+		"define Step_1 as ( [substring] atlimit among ( 's' (delete) ) )":                "x.sbl: no steps found",
+	} {
+		fname := filepath.Join(t.TempDir(), "x.sbl")
+		assert.NoError(t, os.WriteFile(fname, []byte("backwardmode ( "+src+" )"), 0644))
+
+		_, _, _, err := loadSnowball(fname, options{})
+		if msg == "" {
+			assert.NoError(t, err, src)
+		} else if assert.Error(t, err, src) {
+			assert.Equal(t, msg, strings.TrimPrefix(err.Error(), filepath.Dir(fname)+string(filepath.Separator)), src)
+		}
+	}
+
+	// outside of backwardmode, [substring] among searches for prefixes
+	fname := filepath.Join(t.TempDir(), "x.sbl")
+	assert.NoError(t, os.WriteFile(fname, []byte("define Step_1 as ( [substring] among ( 's' (delete) ) )"), 0644))
+	_, _, _, err = loadSnowball(fname, options{})
+	assert.EqualError(t, err, fname+":1: Step_1 isn't in backwardmode, so its among doesn't search for suffixes")
+}
+
+// snowballTest is the test that TestSnowballBuilds adds to porter2, which
+// stems the vocabulary with the steps and regions compiled from english.sbl
+// with the tag Sbl, in place of those that porter2 has.
+const snowballTest = `package porter2
+
+import "testing"
+
+func stemSbl[T letter](rs []T) []T {
+	if len(rs) <= 2 {
+		return rs
+	}
+
+	rs, ex := exception1(rs)
+	if ex {
+		return rs
+	}
+
+	if rs = preclude(rs); len(rs) == 0 {
+		return rs
+	}
+
+	r1, r2 := markRegionsSbl(rs)
+
+	rs = step1a2Sbl(step1aSbl(rs))
+	if exception2(rs) {
+		return postlude(rs)
+	}
+
+	return postlude(step5Sbl(step4Sbl(step3Sbl(step2Sbl(step1cSbl(step1bSbl(rs, r1)), r1), r1, r2), r2), r1, r2))
+}
+
+func TestSnowballVoc(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	for i, word := range words {
+		if got := string(stemSbl([]rune(word))); got != stems[i] {
+			t.Errorf("%s: got %s, expected %s", word, got, stems[i])
+		}
+
+		if got := string(stemSbl([]byte(word))); got != stems[i] {
+			t.Errorf("%s: got %s, expected %s as bytes", word, got, stems[i])
+		}
+	}
+}
+`
+
+// TestSnowballBuilds generates steps_gen.go for porter2 from the Snowball
+// english stemmer, as in the README, and checks that porter2 builds and passes
+// its tests with it in place of the one generated from the specs. It also adds
+// all of english.sbl compiled with the tag Sbl, and checks that it stems the
+// same as porter2 with snowballTest.
+func TestSnowballBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds porter2")
+	}
+
+	dir := t.TempDir()
+
+	var b bytes.Buffer
+	assert.NoError(t, generateFile(&b, "porter2", []string{"testdata/english.sbl"}, options{rename: englishRename}))
+
+	gen := filepath.Join(dir, "steps_gen.go")
+	assert.NoError(t, os.WriteFile(gen, b.Bytes(), 0644))
+
+	b.Reset()
+	assert.NoError(t, generateFile(&b, "porter2", []string{"testdata/english.sbl"}, options{tag: "Sbl"}))
+
+	sbl := filepath.Join(dir, "steps_sbl_gen.go")
+	assert.NoError(t, os.WriteFile(sbl, b.Bytes(), 0644))
+
+	test := filepath.Join(dir, "snowball_test.go")
+	assert.NoError(t, os.WriteFile(test, []byte(snowballTest), 0644))
+
+	replace := make(map[string]string)
+	for fname, with := range map[string]string{"steps_gen.go": gen, "steps_sbl_gen.go": sbl, "snowball_test.go": test} {
+		orig, err := filepath.Abs(filepath.Join("../..", fname))
+		assert.NoError(t, err)
+		replace[orig] = with
+	}
+
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": replace})
+	assert.NoError(t, err)
+
+	fname := filepath.Join(dir, "overlay.json")
+	assert.NoError(t, os.WriteFile(fname, overlay, 0644))
+
+	cmd := exec.Command("go", "test", "-overlay", fname, "-run", "Voc", "-v", "../..")
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, "%s", out)
+	assert.Contains(t, string(out), "--- PASS: TestSnowballVoc")
+}
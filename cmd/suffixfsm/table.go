@@ -0,0 +1,130 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// writeTable writes the state machine fsm used by the step function name as a
+// loop over transition tables, instead of switch statements. The letters of the
+// suffixes are numbered, and fsm+"Class" maps each byte to its number, or 0 if
+// it isn't in any suffix. fsm+"Next" is the next state for each state and letter
+// number, where 0 means there's none, as no transition leads back to the root.
+// fsm+"Final" tells which states are final.
+//
+// The tables are indexed by byte, so it's an error for a suffix to have a letter
+// that doesn't fit in one.
+func writeTable(b *bytes.Buffer, nodes []*node, fsm, name string) error {
+	// letter numbers, in order, starting at 1
+	classes := make(map[rune]int)
+	for _, n := range nodes {
+		for _, c := range n.c {
+			if c.r > 0xff {
+				return fmt.Errorf("%s: letter %q doesn't fit in a byte, so it can't be in a table", name, c.r)
+			}
+			classes[c.r] = 0
+		}
+	}
+
+	var letters []rune
+	for r := range classes {
+		letters = append(letters, r)
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+
+	for i, r := range letters {
+		classes[r] = i + 1
+	}
+
+	// the smallest type that holds all the states
+	typ := "uint8"
+	switch {
+	case len(nodes) > 1<<16:
+		return fmt.Errorf("%s: %d states don't fit in a table", name, len(nodes))
+	case len(nodes) > 1<<8:
+		typ = "uint16"
+	}
+
+	fmt.Fprintf(b, `// %s runs the state machine for %s over rs, using the transition
+// tables %sNext and %sFinal. It returns the length of the longest
+// suffix found and its end state, or 0, 0 if none is found.
+func %s[T letter](rs []T) (int, int) {
+var (
+		l int = len(rs) // string length
+		m int			// suffix length
+		s int			// state
+		f int			// end state of longgest suffix
+	)
+
+	for i := 0; i < l; i++ {
+		r := rs[l-i-1]
+		if rune(r) > 0xff {
+			break
+		}
+
+		s = int(%sNext[s][%sClass[byte(r)]])
+		if s == 0 {
+			break
+		}
+
+		if %sFinal[s] {
+			m, f = i+1, s
+		}
+	}
+
+	return m, f
+}
+
+`, fsm, name, fsm, fsm, fsm, fsm, fsm, fsm)
+
+	fmt.Fprintf(b, "// %sClass is the column of each byte in %sNext, or 0 if the byte\n", fsm, fsm)
+	b.WriteString("// isn't in any suffix.\n")
+	fmt.Fprintf(b, "var %sClass = [256]uint8{\n", fsm)
+	for i, r := range letters {
+		fmt.Fprintf(b, "%q: %d,\n", r, i+1)
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(b, "// %sNext is the next state for each state and letter, or 0 if there's none.\n", fsm)
+	fmt.Fprintf(b, "var %sNext = [%d][%d]%s{\n", fsm, len(nodes), len(letters)+1, typ)
+	for _, n := range nodes {
+		row := make([]string, len(letters)+1)
+		for i := range row {
+			row[i] = "0"
+		}
+
+		for _, c := range n.c {
+			row[classes[c.r]] = fmt.Sprint(c.s)
+		}
+
+		fmt.Fprintf(b, "{%s}, // %d\n", strings.Join(row, ", "), n.s)
+	}
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(b, "// %sFinal tells which states are final.\n", fsm)
+	fmt.Fprintf(b, "var %sFinal = [%d]bool{\n", fsm, len(nodes))
+	for _, n := range nodes {
+		if n.f {
+			fmt.Fprintf(b, "%d: true, // %s\n", n.s, strings.Join(n.ws, ", "))
+		}
+	}
+	b.WriteString("}\n")
+
+	return nil
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/surgebase/porter2/internal/input"
+)
+
+// Conflator stems words, and records the words that were conflated into each
+// stem along with how often each of them was seen. Stems such as gener or seawe
+// aren't words, so a Conflator built while indexing a corpus can show a real
+// word for a stem instead, e.g., generous or seaweed. It can be saved with
+// WriteTo, and loaded at query time with ParseConflator or LoadConflator.
+//
+// Words are recorded as they're given, so MostFrequent and Shortest give them
+// back as they were seen, e.g., NASA or iPhone, and Seaweed and seaweed are
+// counted as different forms. A Conflator is safe for concurrent use by multiple goroutines.
+type Conflator struct {
+	s Stemmer
+
+	mu    sync.RWMutex
+	forms map[string]map[string]int // how often each word was seen, by its stem
+}
+
+// Form is a word that was conflated into a stem, and how often it was seen.
+type Form struct {
+	Word  string
+	Count int
+}
+
+// NewConflator returns a Conflator that stems words with s, or English if s is nil.
+func NewConflator(s Stemmer) *Conflator {
+	if s == nil {
+		s = English
+	}
+
+	return &Conflator{s: s, forms: make(map[string]map[string]int)}
+}
+
+// Stem returns the stemmed version of word, and records word as a form of its stem.
+func (c *Conflator) Stem(word string) string {
+	stem := c.s.Stem(word)
+	c.Add(stem, word, 1)
+
+	return stem
+}
+
+// AppendStem appends the stemmed version of word to dst and returns the
+// extended buffer, and records word as a form of its stem.
+func (c *Conflator) AppendStem(dst, word []byte) []byte {
+	// word may share its underlying array with dst, as in StemBytes
+	form := string(word)
+
+	n := len(dst)
+	dst = c.s.AppendStem(dst, word)
+	c.Add(string(dst[n:]), form, 1)
+
+	return dst
+}
+
+// Add records that word was seen n more times as a form of stem, e.g., to merge
+// the counts of a corpus that was stemmed elsewhere. Words or stems with tabs or
+// line breaks, and stems starting with #, can't be written by WriteTo, so they
+// aren't recorded.
+func (c *Conflator) Add(stem, word string, n int) {
+	if strings.ContainsAny(stem, "\t\r\n") || strings.ContainsAny(word, "\t\r\n") || strings.HasPrefix(stem, "#") {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	forms, ok := c.forms[stem]
+	if !ok {
+		forms = make(map[string]int, 1)
+		c.forms[stem] = forms
+	}
+
+	forms[word] += n
+}
+
+// Forms returns the words that were conflated into stem, the most frequent first.
+// Words seen equally often are ordered by length, and then alphabetically.
+func (c *Conflator) Forms(stem string) []Form {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	forms := make([]Form, 0, len(c.forms[stem]))
+	for word, n := range c.forms[stem] {
+		forms = append(forms, Form{Word: word, Count: n})
+	}
+
+	sort.Slice(forms, func(i, j int) bool {
+		return forms[i].less(forms[j], false)
+	})
+
+	return forms
+}
+
+// MostFrequent returns the word that was conflated into stem most often, or
+// false if no word was. Ties go to the shortest word, as in Forms.
+func (c *Conflator) MostFrequent(stem string) (string, bool) {
+	return c.best(stem, false)
+}
+
+// Shortest returns the shortest word that was conflated into stem, or false if
+// no word was. Ties go to the most frequent word, and then alphabetically.
+func (c *Conflator) Shortest(stem string) (string, bool) {
+	return c.best(stem, true)
+}
+
+// best returns the first form of stem, ordered by less.
+func (c *Conflator) best(stem string, shortest bool) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var (
+		best  Form
+		found bool
+	)
+
+	for word, n := range c.forms[stem] {
+		f := Form{Word: word, Count: n}
+		if !found || f.less(best, shortest) {
+			best, found = f, true
+		}
+	}
+
+	return best.Word, found
+}
+
+// less orders forms by count, most frequent first, then by length, shortest
+// first, and then alphabetically. If shortest is true, length comes first.
+func (f Form) less(g Form, shortest bool) bool {
+	fl, gl := utf8.RuneCountInString(f.Word), utf8.RuneCountInString(g.Word)
+
+	switch {
+	case shortest && fl != gl:
+		return fl < gl
+	case f.Count != g.Count:
+		return f.Count > g.Count
+	case fl != gl:
+		return fl < gl
+	}
+
+	return f.Word < g.Word
+}
+
+// Stems returns the stems that have forms, sorted.
+func (c *Conflator) Stems() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stems := make([]string, 0, len(c.forms))
+	for stem := range c.forms {
+		stems = append(stems, stem)
+	}
+	sort.Strings(stems)
+
+	return stems
+}
+
+// WriteTo writes the forms of every stem to w, in the format read by
+// ParseConflator, sorted by stem and then as in Forms.
+func (c *Conflator) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+
+	var n int64
+	for _, stem := range c.Stems() {
+		for _, f := range c.Forms(stem) {
+			m, err := fmt.Fprintf(bw, "%s\t%s\t%d\n", stem, f.Word, f.Count)
+			n += int64(m)
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, bw.Flush()
+}
+
+// ParseConflator reads the forms of a Conflator from r, which stems any further
+// words with s, or English if s is nil. Each line has a stem, one of its words,
+// and how often the word was seen, separated by tabs. Blank lines and lines
+// starting with # are ignored, e.g.,
+//
+//	# stem	word	count
+//	seawe	seaweed	12
+//	seawe	seaweeds	3
+//
+// The stem and the word are taken as they are, so a line starting with a tab is
+// for the empty stem, which is what Stem returns for, e.g., two apostrophes and an s.
+func ParseConflator(r io.Reader, s Stemmer) (*Conflator, error) {
+	c := NewConflator(s)
+
+	scan := bufio.NewScanner(r)
+	for n := 1; scan.Scan(); n++ {
+		line := strings.TrimSuffix(scan.Text(), "\r")
+		if strings.TrimSpace(line) == "" || line[0] == '#' {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("porter2: line %d: expected a stem, a word and a count, got %q", n, line)
+		}
+
+		count, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("porter2: line %d: bad count %q", n, fields[2])
+		}
+
+		c.Add(fields[0], fields[1], count)
+	}
+
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// LoadConflator reads the forms of a Conflator from the named file, in the
+// format described in ParseConflator. Files ending in .gz are decompressed.
+func LoadConflator(name string, s Stemmer) (*Conflator, error) {
+	r, err := input.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ParseConflator(r, s)
+}
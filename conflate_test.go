@@ -0,0 +1,224 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConflator(t *testing.T) {
+	c := NewConflator(nil)
+
+	for _, word := range []string{"generously", "generous", "generous", "generously", "generously", "generousness"} {
+		assert.Equal(t, "generous", c.Stem(word), word)
+	}
+	assert.Equal(t, "seawe", string(c.AppendStem(nil, []byte("seaweeds"))))
+
+	assert.Equal(t, []Form{{"generously", 3}, {"generous", 2}, {"generousness", 1}}, c.Forms("generous"))
+
+	word, ok := c.MostFrequent("generous")
+	assert.True(t, ok)
+	assert.Equal(t, "generously", word)
+
+	word, ok = c.Shortest("generous")
+	assert.True(t, ok)
+	assert.Equal(t, "generous", word)
+
+	word, ok = c.MostFrequent("seawe")
+	assert.True(t, ok)
+	assert.Equal(t, "seaweeds", word)
+
+	_, ok = c.MostFrequent("gopher")
+	assert.False(t, ok)
+	_, ok = c.Shortest("gopher")
+	assert.False(t, ok)
+	assert.Empty(t, c.Forms("gopher"))
+
+	// ties go to the shortest word, and then alphabetically
+	c.Add("seawe", "seaweed", 1)
+	word, _ = c.MostFrequent("seawe")
+	assert.Equal(t, "seaweed", word)
+
+	c.Add("gener", "generality", 1)
+	c.Add("gener", "generation", 1)
+	word, _ = c.Shortest("gener")
+	assert.Equal(t, "generality", word)
+
+	assert.Equal(t, []string{"gener", "generous", "seawe"}, c.Stems())
+}
+
+func TestConflatorCase(t *testing.T) {
+	c := NewConflator(nil)
+
+	// words are recorded as they're given
+	for _, word := range []string{"NASA", "iPhone", "iPhones", "iPhone", "Seaweed", "seaweed", "seaweed"} {
+		c.Stem(word)
+	}
+
+	word, _ := c.MostFrequent("nasa")
+	assert.Equal(t, "NASA", word)
+
+	word, _ = c.MostFrequent("iphon")
+	assert.Equal(t, "iPhone", word)
+
+	assert.Equal(t, []Form{{"seaweed", 2}, {"Seaweed", 1}}, c.Forms("seawe"))
+}
+
+func TestConflatorStemBytes(t *testing.T) {
+	c := NewConflator(English)
+
+	// the word and dst share their underlying array, as in StemBytes
+	word := []byte("Generously")
+	assert.Equal(t, "generous", string(c.AppendStem(word[:0], word)))
+
+	assert.Equal(t, []Form{{"Generously", 1}}, c.Forms("generous"))
+}
+
+func TestConflatorVoc(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	c := NewConflator(nil)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+
+			for i := g; i < len(words); i += 4 {
+				assert.Equal(t, stems[i], c.Stem(words[i]), words[i])
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// every word is a form of its stem, and the shortest form is never longer
+	for i, word := range words {
+		shortest, ok := c.Shortest(stems[i])
+		assert.True(t, ok, word)
+		assert.True(t, len(shortest) <= len(word), word)
+	}
+
+	var b bytes.Buffer
+	n, err := c.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(b.Len()), n)
+	assert.Equal(t, len(words), strings.Count(b.String(), "\n"))
+
+	d, err := ParseConflator(&b, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, c.Stems(), d.Stems())
+
+	for _, stem := range stems[:1000] {
+		assert.Equal(t, c.Forms(stem), d.Forms(stem), stem)
+	}
+}
+
+const conflatorFile = `# stem	word	count
+seawe	seaweed	12
+seawe	seaweeds	3
+
+gener	generously	7
+`
+
+func TestConflatorParse(t *testing.T) {
+	c, err := ParseConflator(strings.NewReader(conflatorFile), nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Form{{"seaweed", 12}, {"seaweeds", 3}}, c.Forms("seawe"))
+
+	// a loaded Conflator goes on counting
+	c.Stem("seaweeds")
+	assert.Equal(t, []Form{{"seaweed", 12}, {"seaweeds", 4}}, c.Forms("seawe"))
+
+	var b bytes.Buffer
+	_, err = c.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, "gener\tgenerously\t7\nseawe\tseaweed\t12\nseawe\tseaweeds\t4\n", b.String())
+
+	_, err = ParseConflator(strings.NewReader("seawe\tseaweed\t12\nseawe seaweeds 3\n"), nil)
+	assert.EqualError(t, err, `porter2: line 2: expected a stem, a word and a count, got "seawe seaweeds 3"`)
+
+	_, err = ParseConflator(strings.NewReader("seawe\tseaweed\tmany\n"), nil)
+	assert.EqualError(t, err, `porter2: line 1: bad count "many"`)
+}
+
+func TestConflatorRoundTrip(t *testing.T) {
+	c := NewConflator(nil)
+	for _, word := range []string{"''s", "’’s", "Seaweeds", "seaweed", "generously"} {
+		c.Stem(word)
+	}
+	// ''s and ’’s both stem to ""
+	assert.Equal(t, []Form{{"''s", 1}, {"’’s", 1}}, c.Forms(""))
+	assert.Equal(t, []string{"", "generous", "seawe"}, c.Stems())
+
+	// these can't be written, so they aren't recorded
+	c.Add("sea\tweed", "seaweed", 1)
+	c.Add("seawe", "sea\nweed", 1)
+	c.Add("seawe", "seaweed\r", 1)
+	c.Add("#seawe", "#seaweed", 1)
+
+	var b bytes.Buffer
+	_, err := c.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, "\t''s\t1\n\t’’s\t1\ngenerous\tgenerously\t1\nseawe\tseaweed\t1\nseawe\tSeaweeds\t1\n", b.String())
+
+	d, err := ParseConflator(strings.NewReader(b.String()), nil)
+	assert.NoError(t, err)
+	for _, stem := range c.Stems() {
+		assert.Equal(t, c.Forms(stem), d.Forms(stem), stem)
+	}
+	assert.Equal(t, c.Stems(), d.Stems())
+
+	// CRLF line endings and blank lines with white space
+	d, err = ParseConflator(strings.NewReader("\t''s\t2\r\n  \r\nseawe\tseaweed\t1\r\n"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Form{{"''s", 2}}, d.Forms(""))
+	assert.Equal(t, []Form{{"seaweed", 1}}, d.Forms("seawe"))
+}
+
+func TestConflatorLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	name := filepath.Join(dir, "forms.tsv")
+	assert.NoError(t, os.WriteFile(name, []byte(conflatorFile), 0644))
+
+	f, err := os.Create(name + ".gz")
+	assert.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(conflatorFile))
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, f.Close())
+
+	for _, name := range []string{name, name + ".gz"} {
+		c, err := LoadConflator(name, English)
+		assert.NoError(t, err)
+
+		word, ok := c.MostFrequent(c.Stem("seaweeds"))
+		assert.True(t, ok)
+		assert.Equal(t, "seaweed", word)
+	}
+
+	_, err = LoadConflator(filepath.Join(dir, "missing.tsv"), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eval measures how well a stemmer conflates words, against groups of
+// words that a person has judged to belong together, with the understemming
+// and overstemming indexes of Paice.
+//
+// C. D. Paice, "An Evaluation Method for Stemming Algorithms", SIGIR 1994.
+//
+// A stemmer understems when words of the same group get different stems, e.g.,
+// absorb and absorption, and overstems when words of different groups get the
+// same stem, e.g., general and generous. The understemming index UI is the
+// fraction of the pairs of words in the same group that aren't conflated, and
+// the overstemming index OI is the fraction of the pairs of words in different
+// groups that are. A light stemmer has a high UI and a low OI, and a heavy one
+// the other way round. The stemming weight SW = OI/UI measures how heavy it is.
+package eval
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/surgebase/porter2"
+	"github.com/surgebase/porter2/internal/input"
+)
+
+// Result is the evaluation of a stemmer against a set of groups. The totals are
+// numbers of pairs of words.
+type Result struct {
+	Words  int // words in all of the groups
+	Groups int // groups of words
+
+	DMT int64 // desired merge total, pairs of words in the same group
+	UMT int64 // unachieved merge total, pairs of words in the same group with different stems
+	DNT int64 // desired non-merge total, pairs of words in different groups
+	WMT int64 // wrongly merged total, pairs of words in different groups with the same stem
+
+	UI float64 // understemming index, UMT/DMT
+	OI float64 // overstemming index, WMT/DNT
+	SW float64 // stemming weight, OI/UI, or +Inf if UI is 0 and OI isn't
+}
+
+// Paice stems the words of groups with s, and returns the understemming and
+// overstemming indexes. A group with a single word counts towards the pairs of
+// words in different groups only. A word should be in one group only.
+func Paice(s porter2.Stemmer, groups [][]string) Result {
+	r := Result{Groups: len(groups)}
+
+	// words of each group with each stem, by stem
+	stems := make(map[string]map[int]int64)
+	var sumGroups int64 // sum of the squares of the group sizes
+
+	for g, words := range groups {
+		n := int64(len(words))
+		r.Words += len(words)
+		sumGroups += n * n
+		r.DMT += n * (n - 1) / 2
+
+		// words of the group with each stem
+		counts := make(map[string]int64)
+		for _, word := range words {
+			stem := s.Stem(word)
+			counts[stem]++
+
+			if stems[stem] == nil {
+				stems[stem] = make(map[int]int64)
+			}
+			stems[stem][g]++
+		}
+
+		// pairs of words in the group with different stems, i.e., half the
+		// sum of u*(n-u) over the stems, where u is the words with the stem
+		var sum int64
+		for _, u := range counts {
+			sum += u * u
+		}
+		r.UMT += (n*n - sum) / 2
+	}
+
+	w := int64(r.Words)
+	r.DNT = (w*w - sumGroups) / 2
+
+	// pairs of words with the same stem from different groups
+	for _, counts := range stems {
+		var n, sum int64
+		for _, v := range counts {
+			n += v
+			sum += v * v
+		}
+		r.WMT += (n*n - sum) / 2
+	}
+
+	r.UI = ratio(r.UMT, r.DMT)
+	r.OI = ratio(r.WMT, r.DNT)
+
+	switch {
+	case r.UI > 0:
+		r.SW = r.OI / r.UI
+	case r.OI > 0:
+		r.SW = math.Inf(1)
+	}
+
+	return r
+}
+
+// ratio returns a/b, or 0 if b is 0.
+func ratio(a, b int64) float64 {
+	if b == 0 {
+		return 0
+	}
+
+	return float64(a) / float64(b)
+}
+
+// ParseGroups reads groups of words from r, one group per line, with the words
+// separated by spaces or tabs. Blank lines and lines starting with # are
+// ignored, e.g.,
+//
+//	# words that belong together
+//	absorb absorbed absorbing absorption
+//	general generally generality
+//	generous generously generosity
+func ParseGroups(r io.Reader) ([][]string, error) {
+	var groups [][]string
+
+	seen := make(map[string]int) // line of each word
+
+	scan := bufio.NewScanner(r)
+	for n := 1; scan.Scan(); n++ {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		words := strings.Fields(line)
+		for _, word := range words {
+			if m, ok := seen[word]; ok {
+				return nil, fmt.Errorf("eval: line %d: %q is already in the group on line %d", n, word, m)
+			}
+			seen[word] = n
+		}
+
+		groups = append(groups, words)
+	}
+
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// LoadGroups reads groups of words from the named file, in the format described
+// in ParseGroups. Files ending in .gz are decompressed.
+func LoadGroups(name string) ([][]string, error) {
+	r, err := input.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ParseGroups(r)
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/surgebase/porter2"
+	_ "github.com/surgebase/porter2/porter1"
+)
+
+// mapStemmer stems the words in the map, and leaves the rest as they are.
+type mapStemmer map[string]string
+
+func (m mapStemmer) Stem(s string) string {
+	if stem, ok := m[s]; ok {
+		return stem
+	}
+	return s
+}
+
+func (m mapStemmer) AppendStem(dst, word []byte) []byte {
+	return append(dst, m.Stem(string(word))...)
+}
+
+func TestPaice(t *testing.T) {
+	groups := [][]string{{"a1", "a2", "a3"}, {"b1", "b2"}, {"c1"}}
+	s := mapStemmer{"a1": "x", "a2": "x", "a3": "y", "b1": "y", "b2": "z", "c1": "x"}
+
+	r := Paice(s, groups)
+	assert.Equal(t, 6, r.Words)
+	assert.Equal(t, 3, r.Groups)
+	assert.Equal(t, int64(4), r.DMT)
+	assert.Equal(t, int64(3), r.UMT)
+	assert.Equal(t, int64(11), r.DNT)
+	assert.Equal(t, int64(3), r.WMT)
+	assert.InDelta(t, 0.75, r.UI, 1e-9)
+	assert.InDelta(t, 3.0/11, r.OI, 1e-9)
+	assert.InDelta(t, 4.0/11, r.SW, 1e-9)
+
+	// leaving the words as they are never overstems, and conflating them all
+	// never understems
+	r = Paice(mapStemmer{}, groups)
+	assert.Equal(t, 1.0, r.UI)
+	assert.Equal(t, 0.0, r.OI)
+	assert.Equal(t, 0.0, r.SW)
+
+	r = Paice(mapStemmer{"a1": "x", "a2": "x", "a3": "x", "b1": "x", "b2": "x", "c1": "x"}, groups)
+	assert.Equal(t, 0.0, r.UI)
+	assert.Equal(t, 1.0, r.OI)
+	assert.True(t, math.IsInf(r.SW, 1))
+
+	assert.Equal(t, Result{}, Paice(porter2.English, nil))
+}
+
+func TestPaiceStemmers(t *testing.T) {
+	groups, err := LoadGroups(filepath.Join("testdata", "groups.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, 18, len(groups))
+
+	english := Paice(porter2.English, groups)
+	assert.True(t, english.UI > 0 && english.UI < 0.5, "%+v", english)
+	assert.True(t, english.OI > 0 && english.OI < 0.05, "%+v", english)
+
+	porter, err := porter2.Lookup("porter")
+	assert.NoError(t, err)
+
+	// porter conflates general, generous and generate into gener, which porter2
+	// keeps apart, but splits dies from dying
+	p := Paice(porter, groups)
+	assert.Equal(t, english.DMT, p.DMT)
+	assert.Equal(t, english.DNT, p.DNT)
+	assert.True(t, english.OI < p.OI, "%+v %+v", english, p)
+	assert.True(t, english.UI < p.UI, "%+v %+v", english, p)
+}
+
+func TestParseGroups(t *testing.T) {
+	groups, err := ParseGroups(strings.NewReader("# groups\nabsorb absorbed\n\n  general\tgenerally  \n"))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"absorb", "absorbed"}, {"general", "generally"}}, groups)
+
+	_, err = ParseGroups(strings.NewReader("absorb absorbed\ngeneral absorb\n"))
+	assert.EqualError(t, err, `eval: line 2: "absorb" is already in the group on line 1`)
+
+	_, err = LoadGroups(filepath.Join("testdata", "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/surgebase/porter2/internal/input"
+	"github.com/surgebase/porter2/internal/letters"
+)
+
+// Exceptions are words the stemmer should handle specially, in addition to the
+// exception lists built into the Porter2 algorithm. Words are matched without
+// regard to case or the kind of apostrophe they use, and words of two letters or
+// less are never stemmed, so they are ignored.
+type Exceptions struct {
+	// Stems maps words to the stems they should get. They are consulted at the
+	// same point as the exception1 list, i.e., before any of the steps, so map a
+	// word to itself to leave it as it is (like andes or news).
+	Stems map[string]string
+
+	// Invariants are words that are left as they are. They are consulted at the
+	// same point as the exception2 list (inning, proceed...), following step1a,
+	// and also following step0, so words that step1a would change are covered
+	// too. Since step0 has already run, possessives are stemmed to the invariant,
+	// e.g., the invariant kubernetes also covers kubernetes's. For the same
+	// reason, invariants ending in 's or an apostrophe lose it, so the invariant
+	// jones' is the same as jones.
+	Invariants []string
+}
+
+// WithExceptions adds the exceptions in ex to the stemmer. It can be given more
+// than once, in which case the exceptions are merged, and later stems replace
+// earlier ones for the same word. A nil ex adds no exceptions.
+func WithExceptions(ex *Exceptions) Option {
+	return func(e *english) {
+		if ex == nil {
+			return
+		}
+
+		for word, stem := range ex.Stems {
+			if letters.IsShort(word) {
+				continue
+			}
+
+			if e.stems == nil {
+				e.stems = make(map[string]string)
+			}
+
+			e.stems[strings.Map(lower, word)] = stem
+		}
+
+		for _, word := range ex.Invariants {
+			if letters.IsShort(word) {
+				continue
+			}
+
+			if e.invariants == nil {
+				e.invariants = make(map[string]struct{})
+			}
+
+			// Invariants are matched against the word after preclude, which may
+			// have turned some of the y's into Y's, and step0.
+			e.invariants[string(step0(preclude([]rune(strings.Map(lower, word)))))] = struct{}{}
+		}
+	}
+}
+
+// ParseExceptions reads exceptions from r, one per line. A line with a single
+// word adds an invariant, and a line with a word and its stem, separated by white
+// space, adds a stem. Blank lines and lines starting with # are ignored, e.g.,
+//
+//	# product names
+//	kubernetes
+//	gophers gopher
+func ParseExceptions(r io.Reader) (*Exceptions, error) {
+	ex := &Exceptions{Stems: make(map[string]string)}
+
+	scan := bufio.NewScanner(r)
+	for n := 1; scan.Scan(); n++ {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 1:
+			ex.Invariants = append(ex.Invariants, fields[0])
+
+		case 2:
+			ex.Stems[fields[0]] = fields[1]
+
+		default:
+			return nil, fmt.Errorf("porter2: line %d: expected a word, or a word and its stem, got %q", n, line)
+		}
+	}
+
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+
+	return ex, nil
+}
+
+// LoadExceptions reads exceptions from the named file, in the format described
+// in ParseExceptions. Files ending in .gz are decompressed.
+func LoadExceptions(name string) (*Exceptions, error) {
+	r, err := input.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ParseExceptions(r)
+}
+
+// invariant returns true if rs is one of the user supplied invariants in e,
+// which is nil for the plain algorithm.
+func invariant[T letter](e *english, rs []T) bool {
+	if e == nil || e.invariants == nil {
+		return false
+	}
+
+	_, ok := lookup(e.invariants, rs)
+	return ok
+}
+
+// lookup returns the value for the word rs in m. Map lookups keyed by string(bs)
+// don't allocate, so neither does lookup for ASCII words.
+func lookup[T letter, V any](m map[string]V, rs []T) (V, bool) {
+	switch rs := any(rs).(type) {
+	case []byte:
+		v, ok := m[string(rs)]
+		return v, ok
+
+	case []rune:
+		v, ok := m[string(rs)]
+		return v, ok
+	}
+
+	var v V
+	return v, false
+}
+
+// appendString appends s to rs, as bytes if T is byte, or as runes otherwise.
+func appendString[T letter](rs []T, s string) []T {
+	switch any(*new(T)).(type) {
+	case byte:
+		for i := 0; i < len(s); i++ {
+			rs = append(rs, T(s[i]))
+		}
+
+	default:
+		for _, r := range s {
+			rs = append(rs, T(r))
+		}
+	}
+
+	return rs
+}
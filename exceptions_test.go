@@ -0,0 +1,154 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	exceptionsFile = `# product names
+Kubernetes
+yesterday
+
+# jargon
+gophers   gopher
+cafe      café
+ångströms ångström
+`
+
+	exceptionStems map[string]string = map[string]string{
+		"kubernetes":   "kubernetes",
+		"Kubernetes's": "kubernetes",
+		"kubernetes'":  "kubernetes",
+		"yesterdays":   "yesterday",
+		"gophers":      "gopher",
+		"GOPHERS":      "gopher",
+		"cafe":         "café",
+		"Ångströms":    "ångström",
+
+		// the built-in exceptions and everything else still work
+		"skies":      "sky",
+		"proceeding": "proceed",
+		"generously": "generous",
+		"ab":         "ab",
+	}
+)
+
+func TestExceptionsStem(t *testing.T) {
+	ex, err := ParseExceptions(strings.NewReader(exceptionsFile))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Kubernetes", "yesterday"}, ex.Invariants)
+	assert.Len(t, ex.Stems, 3)
+
+	s := NewEnglish(WithExceptions(ex))
+	for word, expect := range exceptionStems {
+		assert.Equal(t, expect, s.Stem(word), word)
+		assert.Equal(t, expect, string(s.AppendStem(nil, []byte(word))), word)
+	}
+
+	// without the exceptions
+	assert.Equal(t, "kubernet", Stem("kubernetes"))
+	assert.Equal(t, "gopher", Stem("gophers"))
+	assert.Equal(t, "yesterday", Stem("yesterdays"))
+}
+
+func TestExceptionsMap(t *testing.T) {
+	s := NewEnglish(
+		WithExceptions(&Exceptions{Stems: map[string]string{"Gophers": "go", "Atlas": "atlases"}}),
+		WithExceptions(&Exceptions{Stems: map[string]string{"gophers": "gopher"}}),
+	)
+
+	assert.Equal(t, "gopher", s.Stem("gophers"))
+	assert.Equal(t, "atlases", s.Stem("atlas"))
+}
+
+func TestExceptionsNil(t *testing.T) {
+	s := NewEnglish(WithExceptions(nil))
+	assert.Equal(t, "generous", s.Stem("generously"))
+	assert.Equal(t, "generous", string(s.AppendStem(nil, []byte("generously"))))
+}
+
+func TestExceptionsApostrophe(t *testing.T) {
+	s := NewEnglish(WithExceptions(&Exceptions{Invariants: []string{"Jones'", "Hastings’s"}}))
+
+	for _, word := range []string{"jones'", "Jones", "jones's", "jones’"} {
+		assert.Equal(t, "jones", s.Stem(word), word)
+	}
+
+	for _, word := range []string{"hastings's", "hastings", "hastings'"} {
+		assert.Equal(t, "hastings", s.Stem(word), word)
+	}
+
+	assert.Equal(t, "jone", Stem("jones'"))
+	assert.Equal(t, "hast", Stem("hastings"))
+}
+
+func TestExceptionsParseError(t *testing.T) {
+	_, err := ParseExceptions(strings.NewReader("gophers gopher\ncats cat dog\n"))
+	assert.EqualError(t, err, `porter2: line 2: expected a word, or a word and its stem, got "cats cat dog"`)
+}
+
+func TestExceptionsLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	name := filepath.Join(dir, "exceptions.txt")
+	assert.NoError(t, os.WriteFile(name, []byte(exceptionsFile), 0644))
+
+	f, err := os.Create(name + ".gz")
+	assert.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(exceptionsFile))
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, f.Close())
+
+	for _, name := range []string{name, name + ".gz"} {
+		ex, err := LoadExceptions(name)
+		assert.NoError(t, err)
+		assert.Equal(t, "kubernetes", NewEnglish(WithExceptions(ex)).Stem("kubernetes's"))
+	}
+
+	_, err = LoadExceptions(filepath.Join(dir, "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestExceptionsVocOutput(t *testing.T) {
+	ex, err := ParseExceptions(strings.NewReader("zzzzz\nzzzzzz zz\n"))
+	assert.NoError(t, err)
+
+	s := NewEnglish(WithExceptions(ex))
+	bs, stems := loadVocBytes("voc.txt", "output.txt")
+
+	var dst []byte
+	for i, word := range bs {
+		assert.Equal(t, stems[i], s.Stem(string(word)), string(word))
+
+		dst = s.AppendStem(dst[:0], word)
+		assert.Equal(t, stems[i], string(dst), string(word))
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		for _, word := range bs {
+			dst = s.AppendStem(dst[:0], word)
+		}
+	})
+	assert.Equal(t, 0.0, allocs)
+}
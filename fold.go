@@ -0,0 +1,136 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"strings"
+	"unicode"
+)
+
+// accents maps the ASCII letters to the lower case letters of Latin-1 and Latin
+// Extended-A and -B that fold to them. Ligatures and digraphs fold to two
+// letters, e.g., æ to ae and ß to ss.
+var accents = map[string]string{
+	"a":  "àáâãäåāăąǎǟǡǻȁȃȧ",
+	"b":  "ƀɓ",
+	"c":  "çćĉċčƈ",
+	"d":  "ďđðɗ",
+	"e":  "èéêëēĕėęěȅȇȩ",
+	"f":  "ƒ",
+	"g":  "ĝğġģǥǧǵ",
+	"h":  "ĥħȟ",
+	"i":  "ìíîïĩīĭįıǐȉȋ",
+	"j":  "ĵǰ",
+	"k":  "ķĸǩƙ",
+	"l":  "ĺļľŀłƚ",
+	"n":  "ñńņňŉŋǹ",
+	"o":  "òóôõöøōŏőơǒǫǭǿȍȏȫȭȯȱ",
+	"r":  "ŕŗřȑȓ",
+	"s":  "śŝşšſș",
+	"t":  "ţťŧțƭ",
+	"u":  "ùúûüũūŭůűųưǔǖǘǚǜȕȗ",
+	"w":  "ŵ",
+	"y":  "ýÿŷȳƴ",
+	"z":  "źżžƶȥ",
+	"ae": "æǣǽ",
+	"dz": "ǆǳ",
+	"ij": "ĳ",
+	"lj": "ǉ",
+	"nj": "ǌ",
+	"oe": "œ",
+	"ss": "ß",
+	"th": "þ",
+}
+
+// folds maps each of the letters in accents to the ASCII letters it folds to.
+var folds = make(map[rune][]rune)
+
+func init() {
+	for ascii, letters := range accents {
+		for _, r := range letters {
+			folds[r] = []rune(ascii)
+		}
+	}
+}
+
+// WithAccentFolding removes the accents from the letters of Latin-1 and Latin
+// Extended-A and -B before stemming, along with combining accents, so words
+// like naïve, café and façades stem the same as naive, cafe and facades.
+// Stems are always in ASCII then, unless the word has letters of other scripts.
+// Words of two letters or less are folded and lower cased too, though not
+// stemmed, so dé gives de and Él gives el. Exceptions are folded the same way.
+func WithAccentFolding() Option {
+	return func(e *english) {
+		e.fold = true
+	}
+}
+
+// appendLower appends the lower case form of r to rs, with its accents removed
+// if fold is true.
+func appendLower(rs []rune, r rune, fold bool) []rune {
+	r = lower(r)
+	if fold {
+		return appendFolded(rs, r)
+	}
+
+	return append(rs, r)
+}
+
+// appendFolded appends r to rs with its accents removed, or nothing if r is a
+// combining accent.
+func appendFolded(rs []rune, r rune) []rune {
+	if f, ok := folds[r]; ok {
+		return append(rs, f...)
+	}
+
+	if unicode.Is(unicode.Mn, r) {
+		return rs
+	}
+
+	return append(rs, r)
+}
+
+// foldString returns s with its accents removed.
+func foldString(s string) string {
+	rs := make([]rune, 0, len(s))
+	for _, r := range s {
+		rs = appendFolded(rs, r)
+	}
+
+	return string(rs)
+}
+
+// foldExceptions removes the accents from the words of the exceptions in e, as
+// they're matched against words with their accents removed when e folds them.
+func (e *english) foldExceptions() {
+	if e.stems != nil {
+		stems := make(map[string]string, len(e.stems))
+		for word, stem := range e.stems {
+			stems[foldString(word)] = stem
+		}
+		e.stems = stems
+	}
+
+	if e.invariants != nil {
+		invariants := make(map[string]struct{}, len(e.invariants))
+		for word := range e.invariants {
+			// a folded letter may be a vowel, which turns the y after it into Y,
+			// so preclude runs again over the folded word
+			word = foldString(strings.ReplaceAll(word, "Y", "y"))
+			invariants[string(preclude([]rune(word)))] = struct{}{}
+		}
+		e.invariants = invariants
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/surgebase/porter2/internal/letters"
+)
+
+func TestEnglishLatin(t *testing.T) {
+	for word, expect := range map[string]string{
+		// two letters or less, counted in runes, are left as they are
+		"né": "né",
+		"Ça": "Ça",
+		"où": "où",
+		"ǅe": "ǅe",
+
+		// Latin-1
+		"Müller's":  "müller",
+		"garçons":   "garçon",
+		"piñatas":   "piñata",
+		"éléphants": "éléphant",
+		"Ægean":     "ægean",
+		"straße":    "straße",
+
+		// Latin Extended-A and -B
+		"Œuvres":    "œuvr",
+		"Łódź":      "łódź",
+		"Dvořák":    "dvořák",
+		"Șeful":     "șeful",
+		"ĳsselmeer": "ĳsselmeer",
+	} {
+		assert.Equal(t, expect, Stem(word), word)
+		assert.Equal(t, expect, string(AppendStem(nil, []byte(word))), word)
+		assert.Equal(t, expect, StemTrace(word).Stem, word)
+	}
+}
+
+func TestEnglishAccentFolding(t *testing.T) {
+	s := NewEnglish(WithAccentFolding())
+
+	for word, ascii := range map[string]string{
+		"naïve":       "naive",
+		"café":        "cafe",
+		"Cafés":       "cafes",
+		"cafe\u0301s": "cafes", // e followed by a combining acute accent
+		"naïveté":     "naivete",
+		"coöperation": "cooperation",
+		"façades":     "facades",
+		"résumés":     "resumes",
+		"Ångströms":   "angstroms",
+		"Æsthetics":   "aesthetics",
+		"jalapeños":   "jalapenos",
+		"éléphants":   "elephants",
+		"Œuvres":      "oeuvres",
+		"Łódź":        "lodz",
+		"Dvořák":      "dvorak",
+		"Șeful":       "seful",
+		"ǅemal":       "dzemal",
+		"ĳsselmeer":   "ijsselmeer",
+		"straße":      "strasse",
+	} {
+		expect := Stem(ascii)
+		assert.Equal(t, expect, s.Stem(word), word)
+		assert.Equal(t, expect, string(s.AppendStem(nil, []byte(word))), word)
+	}
+
+	// two letters or less are folded and lower cased, but not stemmed, and
+	// counted once folded
+	for word, expect := range map[string]string{
+		"né":                 "ne",
+		"Él":                 "el",
+		"dé":                 "de",
+		"OK":                 "ok",
+		"æs":                 "ae", // three letters once folded, and stemmed like aes
+		"e\u0301s":           "es",
+		"\u0301\u0301\u0301": "",
+	} {
+		assert.Equal(t, expect, s.Stem(word), word)
+		assert.Equal(t, expect, string(s.AppendStem(nil, []byte(word))), word)
+	}
+
+	// ASCII words stem the same as without folding
+	words, stems := loadVoc("voc.txt", "output.txt")
+	for i, word := range words {
+		assert.Equal(t, stems[i], s.Stem(word), word)
+	}
+}
+
+func TestFoldLatin(t *testing.T) {
+	// every lower case letter of Latin-1 and Latin Extended-A, in either case,
+	// folds to ASCII letters
+	for r := rune(0xc0); r <= 0x17f; r++ {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		folded := foldString(string(lower(r)))
+		assert.True(t, letters.IsASCII(folded) && folded != "", "%c folds to %q", r, folded)
+	}
+
+	for r, f := range folds {
+		assert.True(t, unicode.IsLower(r), "%c", r)
+		assert.Equal(t, strings.ToLower(string(f)), string(f), "%c", r)
+	}
+}
+
+func TestAccentFoldingExceptions(t *testing.T) {
+	ex := &Exceptions{
+		Stems:      map[string]string{"Cafés": "cafe"},
+		Invariants: []string{"naïveté", "Ayé"},
+	}
+
+	// the exceptions are folded whichever order the options come in
+	for _, s := range []Stemmer{
+		NewEnglish(WithExceptions(ex), WithAccentFolding()),
+		NewEnglish(WithAccentFolding(), WithExceptions(ex)),
+	} {
+		assert.Equal(t, "cafe", s.Stem("cafés"))
+		assert.Equal(t, "cafe", s.Stem("cafes"))
+		assert.Equal(t, "naivete", s.Stem("Naïveté"))
+		assert.Equal(t, "naivete", s.Stem("naivete"))
+		assert.Equal(t, "aye", s.Stem("ayé"))
+	}
+}
+
+func TestAccentFoldingAllocs(t *testing.T) {
+	s := NewEnglish(WithAccentFolding())
+	word := []byte("Façades")
+	dst := make([]byte, 0, 64)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		dst = s.AppendStem(dst[:0], word)
+	})
+	assert.Equal(t, 0.0, allocs)
+	assert.Equal(t, "facad", string(dst))
+}
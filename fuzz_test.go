@@ -0,0 +1,209 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/surgebase/porter2/internal/letters"
+)
+
+// The fuzz targets run over their seed corpus, the vocabulary and fuzzEdges, as
+// part of go test. To fuzz one of them, e.g., for a minute:
+//
+//	go test -run '^$' -fuzz '^FuzzStem$' -fuzztime 1m
+
+// fuzzEdges are words at the edges of the algorithm: apostrophes that leave
+// nothing behind once they're removed, y's, the special R1 prefixes, letters
+// whose lower case is longer in UTF-8, other scripts, invalid UTF-8, words of
+// two letters or less with letters of more than one byte, and combining accents
+// that leave nothing behind once they're folded.
+var fuzzEdges = []string{
+	"", "'", "''", "'''", "'s", "'s'", "s'", "’", "‘’", "’s", "'’'",
+	"y", "Y", "'y", "yy", "'yy", "ay", "ayy'", "yay",
+	"gener", "commun", "arsen", "generic", "communal",
+	"naïve", "café", "Ⱥȿ", "İstanbul", "ǅemal", "ﬁxing", "straße",
+	"日本語", "дома", "مرحبا", "abcδεζ", "étude",
+	"\xff", "\xff\xfe", "ab\x80", "\xc3", "sses\xed\xa0\x80",
+	"é", "éé", "ñu", "aé", "ß", "ǅ", "ÿs", "'é", "é's",
+	"\u0301\u0301\u0301", "e\u0301\u0301", "Él",
+}
+
+// fuzzSeeds adds the vocabulary and fuzzEdges to the seed corpus of f.
+func fuzzSeeds(f *testing.F) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+	for _, word := range words {
+		f.Add(word)
+	}
+
+	for _, word := range fuzzEdges {
+		f.Add(word)
+	}
+}
+
+// lowerRunes returns word as a lower case rune slice, the way Stem decodes it.
+func lowerRunes(word string) []rune {
+	rs := []rune(word)
+	for i, r := range rs {
+		rs[i] = lower(r)
+	}
+
+	return rs
+}
+
+func FuzzStem(f *testing.F) {
+	fuzzSeeds(f)
+
+	folding := NewEnglish(WithAccentFolding())
+
+	f.Fuzz(func(t *testing.T, word string) {
+		stem := Stem(word)
+
+		if utf8.ValidString(word) && !utf8.ValidString(stem) {
+			t.Errorf("Stem(%q) = %q, which is not valid UTF-8", word, stem)
+		}
+
+		if n := utf8.RuneCountInString(word); utf8.RuneCountInString(stem) > n+1 {
+			t.Errorf("Stem(%q) = %q, which is longer than %d runes", word, stem, n+1)
+		}
+
+		if b := string(AppendStem(nil, []byte(word))); b != stem {
+			t.Errorf("AppendStem(%q) = %q, Stem = %q", word, b, stem)
+		}
+
+		// folded letters may become two, e.g., æ to ae, so only the two forms are compared
+		if fs, b := folding.Stem(word), string(folding.AppendStem(nil, []byte(word))); b != fs {
+			t.Errorf("folding AppendStem(%q) = %q, Stem = %q", word, b, fs)
+		}
+	})
+}
+
+func FuzzPreclude(f *testing.F) {
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, word string) {
+		rs := lowerRunes(word)
+		n := len(rs)
+
+		rs = preclude(rs)
+		if len(rs) > n {
+			t.Errorf("preclude(%q) = %q, which is longer", word, string(rs))
+		}
+
+		if letters.IsASCII(word) {
+			if bs := preclude(letters.ToLowerASCII([]byte(word))); string(bs) != string(rs) {
+				t.Errorf("preclude(%q) = %q over bytes, %q over runes", word, bs, string(rs))
+			}
+		}
+	})
+}
+
+func FuzzMarkR1R2(f *testing.F) {
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, word string) {
+		rs := preclude(lowerRunes(word))
+
+		r1, r2 := markR1R2(rs)
+		if r1 < 0 || r1 > r2 || r2 > len(rs) {
+			t.Errorf("markR1R2(%q) = %d, %d, out of range", string(rs), r1, r2)
+		}
+
+		if letters.IsASCII(word) {
+			b1, b2 := markR1R2([]byte(string(rs)))
+			if b1 != r1 || b2 != r2 {
+				t.Errorf("markR1R2(%q) = %d, %d over bytes, %d, %d over runes", string(rs), b1, b2, r1, r2)
+			}
+		}
+	})
+}
+
+// fuzzStep runs step over the lower case and precluded form of word, with the
+// regions marked, as stem would, over both runes and, for ASCII words, bytes.
+// The step may add at most one letter, and must do the same over both.
+func fuzzStep(f *testing.F, name string, step func([]rune, int, int) []rune, bstep func([]byte, int, int) []byte) {
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, word string) {
+		rs := preclude(lowerRunes(word))
+		r1, r2 := markR1R2(rs)
+		in := string(rs)
+
+		out := string(step(rs, r1, r2))
+
+		if n := utf8.RuneCountInString(in); utf8.RuneCountInString(out) > n+1 {
+			t.Errorf("%s(%q) = %q, which is longer than %d runes", name, in, out, n+1)
+		}
+
+		if !utf8.ValidString(out) {
+			t.Errorf("%s(%q) = %q, which is not valid UTF-8", name, in, out)
+		}
+
+		if letters.IsASCII(word) {
+			if bs := bstep([]byte(in), r1, r2); string(bs) != out {
+				t.Errorf("%s(%q) = %q over bytes, %q over runes", name, in, bs, out)
+			}
+		}
+	})
+}
+
+func FuzzStep0(f *testing.F) {
+	fuzzStep(f, "step0",
+		func(rs []rune, r1, r2 int) []rune { return step0(rs) },
+		func(bs []byte, r1, r2 int) []byte { return step0(bs) })
+}
+
+func FuzzStep1a(f *testing.F) {
+	fuzzStep(f, "step1a",
+		func(rs []rune, r1, r2 int) []rune { return step1a(rs) },
+		func(bs []byte, r1, r2 int) []byte { return step1a(bs) })
+}
+
+func FuzzStep1b(f *testing.F) {
+	fuzzStep(f, "step1b",
+		func(rs []rune, r1, r2 int) []rune { return step1b(rs, r1) },
+		func(bs []byte, r1, r2 int) []byte { return step1b(bs, r1) })
+}
+
+func FuzzStep1c(f *testing.F) {
+	fuzzStep(f, "step1c",
+		func(rs []rune, r1, r2 int) []rune { return step1c(rs) },
+		func(bs []byte, r1, r2 int) []byte { return step1c(bs) })
+}
+
+func FuzzStep2(f *testing.F) {
+	fuzzStep(f, "step2",
+		func(rs []rune, r1, r2 int) []rune { return step2(rs, r1) },
+		func(bs []byte, r1, r2 int) []byte { return step2(bs, r1) })
+}
+
+func FuzzStep3(f *testing.F) {
+	fuzzStep(f, "step3",
+		func(rs []rune, r1, r2 int) []rune { return step3(rs, r1, r2) },
+		func(bs []byte, r1, r2 int) []byte { return step3(bs, r1, r2) })
+}
+
+func FuzzStep4(f *testing.F) {
+	fuzzStep(f, "step4",
+		func(rs []rune, r1, r2 int) []rune { return step4(rs, r2) },
+		func(bs []byte, r1, r2 int) []byte { return step4(bs, r2) })
+}
+
+func FuzzStep5(f *testing.F) {
+	fuzzStep(f, "step5",
+		func(rs []rune, r1, r2 int) []rune { return step5(rs, r1, r2) },
+		func(bs []byte, r1, r2 int) []byte { return step5(bs, r1, r2) })
+}
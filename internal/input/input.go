@@ -0,0 +1,65 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package input opens the files that porter2 and its commands read word lists
+// from, which may be gzipped.
+package input
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+// gzipFile is a decompressed file, which closes the file along with the reader.
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
+}
+
+// Open opens the named file for reading, and decompresses it if the name ends
+// in .gz.
+func Open(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(name, ".gz") {
+		return f, nil
+	}
+
+	gunzip, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return gzipFile{gunzip, f}, nil
+}
+
+// OpenArg is Open for the file arguments of the commands, where - is stdin.
+func OpenArg(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	return Open(name)
+}
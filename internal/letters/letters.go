@@ -0,0 +1,83 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package letters has what the porter2 and porter1 stemmers share to run their
+// state machines over the letters of a word: as bytes for ASCII words, which is
+// the common case for english text, and as runes otherwise, in buffers on the
+// stack for words of up to StackRunes runes.
+package letters
+
+import "unicode/utf8"
+
+// Letter is the element type the state machines operate on. Words that are
+// pure ASCII are stemmed directly as bytes; everything else is decoded into
+// runes first.
+type Letter interface {
+	byte | rune
+}
+
+// StackRunes is the number of runes AppendStem and StemBytes can stem without
+// allocating. Longer words are still stemmed correctly, they just spill to the heap.
+const StackRunes = 64
+
+// IsASCII returns true if s has no bytes outside of ASCII.
+func IsASCII[S string | []byte](s S) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ToLowerASCII lower cases the ASCII letters of bs in place, and returns bs.
+func ToLowerASCII(bs []byte) []byte {
+	for i, b := range bs {
+		if 'A' <= b && b <= 'Z' {
+			bs[i] = b + 'a' - 'A'
+		}
+	}
+
+	return bs
+}
+
+// IsShort returns true if s has two letters or less, which neither stemmer
+// changes. The letters are counted in runes, rather than bytes, so two letter
+// words with accents are short too.
+func IsShort[S string | []byte](s S) bool {
+	if len(s) <= 2 {
+		return true
+	}
+
+	if len(s) > 2*utf8.UTFMax {
+		return false
+	}
+
+	n := 0
+	for range string(s) {
+		n++
+	}
+
+	return n <= 2
+}
+
+// AppendRunes appends the UTF-8 encoding of rs to dst and returns the extended buffer.
+func AppendRunes(dst []byte, rs []rune) []byte {
+	for _, r := range rs {
+		dst = utf8.AppendRune(dst, r)
+	}
+
+	return dst
+}
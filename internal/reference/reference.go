@@ -0,0 +1,462 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reference is a slow and simple implementation of the Snowball english
+// stemmer, written straight from the description of the algorithm with string
+// comparisons, rather than state machines.
+//
+// http://snowball.tartarus.org/algorithms/english/stemmer.html
+//
+// It exists only so the tests of porter2 can check Stem against it, step by
+// step, and is not meant to be used otherwise. Like porter2, it lower cases the
+// word first, and treats the curly apostrophes U+2018, U+2019 and U+201B as '.
+package reference
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Result is the word after each stage of the algorithm.
+type Result struct {
+	// Exception1 is true if the word is in the exception1 list, in which case
+	// Stem is the replacement, and none of the other fields are set.
+	Exception1 bool
+
+	// Preclude is the word after it's lower cased, the initial ' is removed, and
+	// y's are marked as consonants (Y).
+	Preclude string
+
+	// R1 and R2 are the rune offsets of the R1 and R2 regions in Preclude.
+	R1, R2 int
+
+	// Steps are the steps in the order they ran, starting with step0.
+	Steps []Step
+
+	// Exception2 is true if the word is in the exception2 list following step1a,
+	// in which case the rest of the steps did not run.
+	Exception2 bool
+
+	// Stem is the final result after postlude.
+	Stem string
+}
+
+// Step is the word after one of the steps, e.g., step2.
+type Step struct {
+	Name, Word string
+}
+
+// exception1 are the words that are stemmed specially, or left as they are.
+var exception1 = map[string]string{
+	"skis":   "ski",
+	"skies":  "sky",
+	"dying":  "die",
+	"lying":  "lie",
+	"tying":  "tie",
+	"idly":   "idl",
+	"gently": "gentl",
+	"ugly":   "ugli",
+	"early":  "earli",
+	"only":   "onli",
+	"singly": "singl",
+	"sky":    "sky",
+	"news":   "news",
+	"howe":   "howe",
+	"atlas":  "atlas",
+	"cosmos": "cosmos",
+	"bias":   "bias",
+	"andes":  "andes",
+}
+
+// exception2 are the words that are left as they are following step1a.
+var exception2 = map[string]bool{
+	"inning":  true,
+	"outing":  true,
+	"canning": true,
+	"herring": true,
+	"earring": true,
+	"proceed": true,
+	"exceed":  true,
+	"succeed": true,
+}
+
+// Stem returns the stem of word.
+func Stem(word string) string {
+	return Run(word).Stem
+}
+
+// Run stems word, and records the word after each stage.
+func Run(word string) Result {
+	res := Result{Stem: word}
+
+	// If the word has two letters or less, leave it as it is.
+	if utf8.RuneCountInString(word) <= 2 {
+		return res
+	}
+
+	w := strings.NewReplacer("‘", "'", "’", "'", "‛", "'").Replace(strings.ToLower(word))
+
+	if stem, ok := exception1[w]; ok {
+		res.Exception1 = true
+		res.Stem = stem
+		return res
+	}
+
+	w = prelude(w)
+	res.Preclude = w
+
+	if w == "" {
+		res.Stem = w
+		return res
+	}
+
+	// the regions are byte offsets here, which stay put as suffixes are removed
+	p1, p2 := regions(w)
+	res.R1, res.R2 = utf8.RuneCountInString(w[:p1]), utf8.RuneCountInString(w[:p2])
+
+	step := func(name string, f func(string) string) {
+		w = f(w)
+		res.Steps = append(res.Steps, Step{Name: name, Word: w})
+	}
+
+	step("step0", step0)
+	step("step1a", step1a)
+
+	if exception2[w] {
+		res.Exception2 = true
+		res.Stem = postlude(w)
+		return res
+	}
+
+	step("step1b", func(w string) string { return step1b(w, p1) })
+	step("step1c", step1c)
+	step("step2", func(w string) string { return step2(w, p1) })
+	step("step3", func(w string) string { return step3(w, p1, p2) })
+	step("step4", func(w string) string { return step4(w, p2) })
+	step("step5", func(w string) string { return step5(w, p1, p2) })
+
+	res.Stem = postlude(w)
+
+	return res
+}
+
+// isVowel returns true if r is one of a, e, i, o, u and y.
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouy", r)
+}
+
+// last returns the last letter of w, or 0 if w is empty.
+func last(w string) rune {
+	if w == "" {
+		return 0
+	}
+
+	r, _ := utf8.DecodeLastRuneInString(w)
+	return r
+}
+
+// prelude removes the initial ', if present, and then sets the initial y, and
+// every y after a vowel, to Y.
+func prelude(w string) string {
+	w = strings.TrimPrefix(w, "'")
+
+	var b strings.Builder
+	prev := rune(0)
+	for i, r := range w {
+		if r == 'y' && (i == 0 || isVowel(prev)) {
+			r = 'Y'
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+
+	return b.String()
+}
+
+// regions returns the byte offsets of R1 and R2 in w. R1 is the region after the
+// first non-vowel following a vowel, and R2 is the same within R1. Either is the
+// end of the word if there's no such non-vowel. If the word begins with gener,
+// commun or arsen, R1 is the rest of the word instead.
+func regions(w string) (int, int) {
+	p1 := -1
+	for _, prefix := range []string{"gener", "commun", "arsen"} {
+		if strings.HasPrefix(w, prefix) {
+			p1 = len(prefix)
+		}
+	}
+
+	if p1 < 0 {
+		p1 = region(w, 0)
+	}
+
+	return p1, region(w, p1)
+}
+
+// region returns the byte offset after the first non-vowel following a vowel
+// in w, starting at from, or len(w) if there's none.
+func region(w string, from int) int {
+	prev := rune(0)
+	for i, r := range w[from:] {
+		if prev != 0 && isVowel(prev) && !isVowel(r) {
+			return from + i + utf8.RuneLen(r)
+		}
+		prev = r
+	}
+
+	return len(w)
+}
+
+// longest returns the longest of suffixes that w ends with, or "" if none.
+func longest(w string, suffixes ...string) string {
+	var found string
+	for _, s := range suffixes {
+		if strings.HasSuffix(w, s) && len(s) > len(found) {
+			found = s
+		}
+	}
+
+	return found
+}
+
+// isShortSyllable returns true if w ends with a short syllable, i.e., a vowel
+// followed by a non-vowel other than w, x or Y and preceded by a non-vowel, or
+// a vowel at the beginning of the word followed by a non-vowel.
+func isShortSyllable(w string) bool {
+	rs := []rune(w)
+	n := len(rs)
+
+	switch {
+	case n == 2:
+		return isVowel(rs[0]) && !isVowel(rs[1])
+	case n >= 3:
+		return !isVowel(rs[n-3]) && isVowel(rs[n-2]) && !isVowel(rs[n-1]) && !strings.ContainsRune("wxY", rs[n-1])
+	}
+
+	return false
+}
+
+// isShort returns true if w ends with a short syllable and R1 is null.
+func isShort(w string, p1 int) bool {
+	return p1 >= len(w) && isShortSyllable(w)
+}
+
+// Step 0: remove the longest of ', 's and 's'.
+func step0(w string) string {
+	s := longest(w, "'", "'s", "'s'")
+	return w[:len(w)-len(s)]
+}
+
+// Step 1a: replace sses by ss; replace ied and ies by i if preceded by more than
+// one letter, otherwise by ie; leave us and ss; delete s if the word part before
+// it contains a vowel not immediately before the s.
+func step1a(w string) string {
+	s := longest(w, "sses", "ied", "ies", "us", "ss", "s")
+	stem := w[:len(w)-len(s)]
+
+	switch s {
+	case "sses":
+		return stem + "ss"
+
+	case "ied", "ies":
+		if utf8.RuneCountInString(stem) > 1 {
+			return stem + "i"
+		}
+		return stem + "ie"
+
+	case "s":
+		_, n := utf8.DecodeLastRuneInString(stem)
+		if strings.ContainsAny(stem[:len(stem)-n], "aeiouy") {
+			return stem
+		}
+	}
+
+	return w
+}
+
+// Step 1b: replace eed and eedly by ee if in R1; delete ed, edly, ing and ingly
+// if the word part before them contains a vowel, and then add e if the word ends
+// with at, bl or iz, remove the last letter if it ends with a double, or add e
+// if the word is short.
+func step1b(w string, p1 int) string {
+	s := longest(w, "eed", "eedly", "ed", "edly", "ing", "ingly")
+	stem := w[:len(w)-len(s)]
+
+	switch s {
+	case "eed", "eedly":
+		if len(stem) >= p1 {
+			return stem + "ee"
+		}
+
+	case "ed", "edly", "ing", "ingly":
+		if !strings.ContainsAny(stem, "aeiouy") {
+			return w
+		}
+
+		switch {
+		case strings.HasSuffix(stem, "at"), strings.HasSuffix(stem, "bl"), strings.HasSuffix(stem, "iz"):
+			return stem + "e"
+
+		case longest(stem, "bb", "dd", "ff", "gg", "mm", "nn", "pp", "rr", "tt") != "":
+			return stem[:len(stem)-1]
+
+		case isShort(stem, p1):
+			return stem + "e"
+		}
+
+		return stem
+	}
+
+	return w
+}
+
+// Step 1c: replace the final y or Y by i if preceded by a non-vowel which is not
+// the first letter of the word.
+func step1c(w string) string {
+	r := last(w)
+	if r != 'y' && r != 'Y' {
+		return w
+	}
+
+	stem := w[:len(w)-1]
+	if utf8.RuneCountInString(stem) > 1 && !isVowel(last(stem)) {
+		return stem + "i"
+	}
+
+	return w
+}
+
+// step2Suffixes are the suffixes of step 2 and their replacements.
+var step2Suffixes = map[string]string{
+	"tional":  "tion",
+	"enci":    "ence",
+	"anci":    "ance",
+	"abli":    "able",
+	"entli":   "ent",
+	"izer":    "ize",
+	"ization": "ize",
+	"ational": "ate",
+	"ation":   "ate",
+	"ator":    "ate",
+	"alism":   "al",
+	"aliti":   "al",
+	"alli":    "al",
+	"fulness": "ful",
+	"ousli":   "ous",
+	"ousness": "ous",
+	"iveness": "ive",
+	"iviti":   "ive",
+	"biliti":  "ble",
+	"bli":     "ble",
+	"ogi":     "og",
+	"fulli":   "ful",
+	"lessli":  "less",
+	"li":      "",
+}
+
+// Step 2: replace the longest of step2Suffixes if it's in R1, ogi only if it's
+// preceded by l, and li only if it's preceded by a valid li ending.
+func step2(w string, p1 int) string {
+	return replace(w, p1, step2Suffixes, func(s, stem string) bool {
+		switch s {
+		case "ogi":
+			return strings.HasSuffix(stem, "l")
+		case "li":
+			return strings.ContainsRune("cdeghkmnrt", last(stem))
+		}
+		return true
+	})
+}
+
+// step3Suffixes are the suffixes of step 3 and their replacements.
+var step3Suffixes = map[string]string{
+	"tional":  "tion",
+	"ational": "ate",
+	"alize":   "al",
+	"icate":   "ic",
+	"iciti":   "ic",
+	"ical":    "ic",
+	"ful":     "",
+	"ness":    "",
+	"ative":   "",
+}
+
+// Step 3: replace the longest of step3Suffixes if it's in R1, and ative only if
+// it's in R2 too.
+func step3(w string, p1, p2 int) string {
+	return replace(w, p1, step3Suffixes, func(s, stem string) bool {
+		return s != "ative" || len(stem) >= p2
+	})
+}
+
+// step4Suffixes are the suffixes of step 4, which are all deleted.
+var step4Suffixes = map[string]string{
+	"al": "", "ance": "", "ence": "", "er": "", "ic": "", "able": "", "ible": "",
+	"ant": "", "ement": "", "ment": "", "ent": "", "ism": "", "ate": "", "iti": "",
+	"ous": "", "ive": "", "ize": "", "ion": "",
+}
+
+// Step 4: delete the longest of step4Suffixes if it's in R2, and ion only if it's
+// preceded by s or t.
+func step4(w string, p2 int) string {
+	return replace(w, p2, step4Suffixes, func(s, stem string) bool {
+		return s != "ion" || strings.HasSuffix(stem, "s") || strings.HasSuffix(stem, "t")
+	})
+}
+
+// replace replaces the longest of suffixes that w ends with, if it starts at p
+// or later and ok returns true for it and the word part before it.
+func replace(w string, p int, suffixes map[string]string, ok func(s, stem string) bool) string {
+	var s string
+	for suffix := range suffixes {
+		if strings.HasSuffix(w, suffix) && len(suffix) > len(s) {
+			s = suffix
+		}
+	}
+
+	stem := w[:len(w)-len(s)]
+	if s == "" || len(stem) < p || !ok(s, stem) {
+		return w
+	}
+
+	return stem + suffixes[s]
+}
+
+// Step 5: delete e if in R2, or in R1 and not preceded by a short syllable;
+// delete l if in R2 and preceded by l.
+func step5(w string, p1, p2 int) string {
+	if w == "" {
+		return w
+	}
+
+	stem := w[:len(w)-1]
+
+	switch w[len(w)-1] {
+	case 'e':
+		if len(stem) >= p2 || (len(stem) >= p1 && !isShortSyllable(stem)) {
+			return stem
+		}
+
+	case 'l':
+		if len(stem) >= p2 && strings.HasSuffix(stem, "l") {
+			return stem
+		}
+	}
+
+	return w
+}
+
+// postlude turns the remaining Y's back into lower case.
+func postlude(w string) string {
+	return strings.ReplaceAll(w, "Y", "y")
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package porter1 implements the original Porter stemmer, as published by
+// Martin Porter in 1980. Like porter2, it is written using finite state machines
+// to do suffix comparison, and the state machines are generated by
+// [suffixfsm](https://github.com/surgebase/porter2/tree/master/cmd/suffixfsm)
+// from the suffix lists in cmd/suffixfsm/porter1.
+//
+// http://snowball.tartarus.org/algorithms/porter/stemmer.html
+//
+// This implementation follows Martin Porter's reference implementation,
+// https://tartarus.org/martin/PorterStemmer/, rather than the paper where the
+// two differ: words of two letters or less are left as they are, bli is replaced
+// by ble rather than abli by able, and logi by log. The tests check Stem against
+// the vocabulary and output published with the reference implementation, and,
+// word by word, against a transliteration of its C code. Unlike Porter2, there's
+// no special handling of apostrophes or exceptional forms.
+//
+// Importing the package registers the stemmer with porter2 as "porter", so it
+// can be picked with porter2.Lookup:
+//
+//	import _ "github.com/surgebase/porter2/porter1"
+package porter1
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/surgebase/porter2"
+	"github.com/surgebase/porter2/internal/letters"
+)
+
+// The suffix state machines, and the steps that use them, are generated into
+// steps_gen.go from the suffix specs in cmd/suffixfsm/porter1/step*.txt. Run go generate after
+// changing the specs.
+//go:generate go run ../cmd/suffixfsm -pkg porter1 -o steps_gen.go ../cmd/suffixfsm/porter1/step*.txt
+
+// letter is the element type the state machines, including the generated ones,
+// operate on.
+type letter = letters.Letter
+
+// Porter is the original Porter stemmer, i.e., the algorithm behind Stem and
+// AppendStem. It is registered with porter2 as "porter".
+var Porter porter2.Stemmer = porter{}
+
+type porter struct{}
+
+func (porter) Stem(s string) string {
+	return Stem(s)
+}
+
+func (porter) AppendStem(dst, word []byte) []byte {
+	return AppendStem(dst, word)
+}
+
+func init() {
+	porter2.Register("porter", Porter)
+}
+
+// Stem takes a string and returns the stemmed version based on the Porter algorithm.
+func Stem(s string) string {
+	// If the word has two letters or less, leave it as it is.
+	if letters.IsShort(s) {
+		return s
+	}
+
+	// ASCII words are stemmed as lower case bytes, with no decoding needed
+	if letters.IsASCII(s) {
+		var buf [letters.StackRunes]byte
+		return string(stem(letters.ToLowerASCII(append(buf[:0], s...))))
+	}
+
+	// Convert s from string to lower case rune slice
+	rs := []rune(s)
+	for i, r := range rs {
+		rs[i] = unicode.ToLower(r)
+	}
+
+	return string(stem(rs))
+}
+
+// StemBytes stems word in place and returns the stemmed version, which shares
+// the underlying array with word. The original contents of word are overwritten.
+// It does not allocate unless word is longer than 64 runes, or the lower case
+// form of word is longer than cap(word).
+func StemBytes(word []byte) []byte {
+	return AppendStem(word[:0], word)
+}
+
+// AppendStem appends the stemmed version of word to dst and returns the extended
+// buffer. word is not modified, unless it shares its underlying array with dst as
+// in StemBytes. It does not allocate unless word is longer than 64 runes, or dst
+// does not have enough capacity to hold the result.
+func AppendStem(dst, word []byte) []byte {
+	// If the word has two letters or less, leave it as it is.
+	if letters.IsShort(word) {
+		return append(dst, word...)
+	}
+
+	// ASCII words are copied to the end of dst and stemmed right there
+	if letters.IsASCII(word) {
+		n := len(dst)
+		dst = append(dst, word...)
+		return append(dst[:n], stem(letters.ToLowerASCII(dst[n:]))...)
+	}
+
+	// Decode word into a lower case rune slice backed by the stack
+	var buf [letters.StackRunes]rune
+	rs := buf[:0]
+	for i := 0; i < len(word); {
+		r, n := utf8.DecodeRune(word[i:])
+		rs = append(rs, unicode.ToLower(r))
+		i += n
+	}
+
+	return letters.AppendRunes(dst, stem(rs))
+}
+
+// stem runs the Porter algorithm over rs, which must already be in lower case
+// and longer than two letters. rs is modified in place, and the returned slice shares its
+// underlying array.
+func stem[T letter](rs []T) []T {
+	rs = preclude(rs)
+
+	r1, r2 := markR1R2(rs)
+
+	rs = step1a(rs)
+	rs = step1b(rs, r1)
+	rs = step1c(rs)
+	rs = step2(rs, r1)
+	rs = step3(rs, r1)
+	rs = step4(rs, r2)
+	rs = step5a(rs, r1, r2)
+	rs = step5b(rs, r2)
+
+	return postlude(rs)
+}
+
+// Set initial y, or y after a vowel, to Y, so it is treated as a consonant.
+func preclude[T letter](rs []T) []T {
+	if rs[0] == 'y' {
+		rs[0] = 'Y'
+	}
+
+	for i, r := range rs[:len(rs)-1] {
+		if isVowel(r) && rs[i+1] == 'y' {
+			rs[i+1] = 'Y'
+		}
+	}
+
+	return rs
+}
+
+// http://snowball.tartarus.org/texts/r1r2.html
+//
+// R1 is the region after the first non-vowel following a vowel, or is the null
+// region at the end of the word if there is no such non-vowel.
+//
+// R2 is the region after the first non-vowel following a vowel in R1, or is the
+// null region at the end of the word if there is no such non-vowel.
+//
+// A suffix is in R1 exactly when the m of Porter's paper, measured on the stem
+// it leaves behind, is greater than 0, and in R2 when m is greater than 1.
+func markR1R2[T letter](rs []T) (int, int) {
+	r1 := markRegion(rs)
+	return r1, r1 + markRegion(rs[r1:])
+}
+
+func markRegion[T letter](rs []T) int {
+	if len(rs) == 0 {
+		return 0
+	}
+
+	for i, r := range rs[:len(rs)-1] {
+		if isVowel(r) && !isVowel(rs[i+1]) {
+			return i + 2
+		}
+	}
+	return len(rs)
+}
+
+// Replace suffix y or Y by i if the preceding word part contains a vowel
+// (so happy -> happi, sky -> sky)
+func step1c[T letter](rs []T) []T {
+	l := len(rs)
+
+	if l > 1 {
+		switch rs[l-1] {
+		case 'y', 'Y':
+			if hasVowel(rs[:l-1]) {
+				rs[l-1] = 'i'
+			}
+		}
+	}
+
+	return rs
+}
+
+// Delete suffix e if in R2, or in R1 and not preceded by a short syllable
+// (so probate -> probat, rate -> rate, cease -> ceas).
+func step5a[T letter](rs []T, r1, r2 int) []T {
+	l := len(rs)
+
+	if l > 0 && rs[l-1] == 'e' && (l-1 >= r2 || (l-1 >= r1 && !isShortSyllable(rs[:l-1]))) {
+		rs = rs[:l-1]
+	}
+
+	return rs
+}
+
+// Delete suffix l if in R2 and preceded by l (so controll -> control, roll -> roll).
+func step5b[T letter](rs []T, r2 int) []T {
+	l := len(rs)
+
+	if l > 1 && rs[l-1] == 'l' && rs[l-2] == 'l' && l-1 >= r2 {
+		rs = rs[:l-1]
+	}
+
+	return rs
+}
+
+// Finally, turn any remaining Y letters in the word back into lower case.
+func postlude[T letter](rs []T) []T {
+	for i, r := range rs {
+		if r == 'Y' {
+			rs[i] = 'y'
+		}
+	}
+
+	return rs
+}
+
+func isVowel[T letter](r T) bool {
+	switch r {
+	case 'a', 'e', 'i', 'o', 'u', 'y':
+		return true
+	}
+
+	return false
+}
+
+func hasVowel[T letter](rs []T) bool {
+	for _, r := range rs {
+		if isVowel(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fixEnding finishes the ed and ing suffixes of step1b once they're removed:
+//
+//	if the word ends at, bl or iz add e (so conflat -> conflate), or
+//	if the word ends with a double remove the last letter (so hopp -> hop), or
+//	if R1 is null and the word ends with a short syllable, add e (so fil -> file)
+func fixEnding[T letter](rs []T, r1 int) []T {
+	if len(rs) >= 2 {
+		r, rr := rs[len(rs)-1], rs[len(rs)-2]
+
+		// if the word ends at, bl or iz add e (so conflat -> conflate)
+		if (rr == 'a' && r == 't') || (rr == 'b' && r == 'l') || (rr == 'i' && r == 'z') {
+			return append(rs, 'e')
+		}
+
+		// if the word ends with a double remove the last letter (so hopp -> hop)
+		if r == rr {
+			switch r {
+			case 'b', 'd', 'f', 'g', 'm', 'n', 'p', 'r', 't':
+				return rs[:len(rs)-1]
+			}
+		}
+	}
+
+	// if R1 is null and the word ends with a short syllable, add e (so fil -> file)
+	if r1 >= len(rs) && isShortSyllable(rs) {
+		return append(rs, 'e')
+	}
+
+	return rs
+}
+
+// A short syllable, the *o of Porter's paper, is a vowel followed by a non-vowel
+// other than w, x or Y and preceded by a non-vowel (so hop, but not hope, how or
+// hoax). Unlike Porter2, a vowel at the beginning of the word doesn't count.
+func isShortSyllable[T letter](rs []T) bool {
+	l := len(rs)
+	if l < 3 {
+		return false
+	}
+
+	r, rr, rrr := rs[l-1], rs[l-2], rs[l-3]
+
+	return !isVowel(rrr) && isVowel(rr) && !isVowel(r) && r != 'w' && r != 'x' && r != 'Y'
+}
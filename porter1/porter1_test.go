@@ -0,0 +1,333 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter1
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/surgebase/porter2"
+)
+
+// The examples given for each step in Porter's paper, "An algorithm for suffix
+// stripping", 1980.
+var (
+	steps1a map[string]string = map[string]string{
+		"caresses": "caress",
+		"ponies":   "poni",
+		"ties":     "ti",
+		"caress":   "caress",
+		"cats":     "cat",
+	}
+
+	steps1b map[string]string = map[string]string{
+		"feed":      "feed",
+		"agreed":    "agree",
+		"plastered": "plaster",
+		"bled":      "bled",
+		"motoring":  "motor",
+		"sing":      "sing",
+		"conflated": "conflate",
+		"troubled":  "trouble",
+		"sized":     "size",
+		"hopping":   "hop",
+		"tanned":    "tan",
+		"falling":   "fall",
+		"hissing":   "hiss",
+		"fizzed":    "fizz",
+		"failing":   "fail",
+		"filing":    "file",
+	}
+
+	steps1c map[string]string = map[string]string{
+		"happy": "happi",
+		"sky":   "sky",
+	}
+
+	steps2 map[string]string = map[string]string{
+		"relational":     "relate",
+		"conditional":    "condition",
+		"rational":       "rational",
+		"valenci":        "valence",
+		"hesitanci":      "hesitance",
+		"digitizer":      "digitize",
+		"conformabli":    "conformable",
+		"radicalli":      "radical",
+		"differentli":    "different",
+		"vileli":         "vile",
+		"analogousli":    "analogous",
+		"vietnamization": "vietnamize",
+		"predication":    "predicate",
+		"operator":       "operate",
+		"feudalism":      "feudal",
+		"decisiveness":   "decisive",
+		"hopefulness":    "hopeful",
+		"callousness":    "callous",
+		"formaliti":      "formal",
+		"sensitiviti":    "sensitive",
+		"sensibiliti":    "sensible",
+	}
+
+	steps3 map[string]string = map[string]string{
+		"triplicate":  "triplic",
+		"formative":   "form",
+		"formalize":   "formal",
+		"electriciti": "electric",
+		"electrical":  "electric",
+		"hopeful":     "hope",
+		"goodness":    "good",
+	}
+
+	steps4 map[string]string = map[string]string{
+		"revival":     "reviv",
+		"allowance":   "allow",
+		"inference":   "infer",
+		"airliner":    "airlin",
+		"gyroscopic":  "gyroscop",
+		"adjustable":  "adjust",
+		"defensible":  "defens",
+		"irritant":    "irrit",
+		"replacement": "replac",
+		"adjustment":  "adjust",
+		"dependent":   "depend",
+		"adoption":    "adopt",
+		"homologou":   "homolog",
+		"communism":   "commun",
+		"activate":    "activ",
+		"angulariti":  "angular",
+		"homologous":  "homolog",
+		"effective":   "effect",
+		"bowdlerize":  "bowdler",
+		"lion":        "lion",
+	}
+
+	steps5a map[string]string = map[string]string{
+		"probate": "probat",
+		"rate":    "rate",
+		"cease":   "ceas",
+	}
+
+	steps5b map[string]string = map[string]string{
+		"controll": "control",
+		"roll":     "roll",
+	}
+)
+
+func TestPorterStep1a(t *testing.T) {
+	for word, expect := range steps1a {
+		assert.Equal(t, expect, string(step1a([]rune(word))), word)
+	}
+}
+
+func TestPorterStep1b(t *testing.T) {
+	for word, expect := range steps1b {
+		rs := []rune(word)
+		r1, _ := markR1R2(rs)
+		assert.Equal(t, expect, string(step1b(rs, r1)), word)
+	}
+}
+
+func TestPorterStep1c(t *testing.T) {
+	for word, expect := range steps1c {
+		assert.Equal(t, expect, string(step1c([]rune(word))), word)
+	}
+}
+
+func TestPorterStep2(t *testing.T) {
+	for word, expect := range steps2 {
+		rs := []rune(word)
+		r1, _ := markR1R2(rs)
+		assert.Equal(t, expect, string(step2(rs, r1)), word)
+	}
+}
+
+func TestPorterStep3(t *testing.T) {
+	for word, expect := range steps3 {
+		rs := []rune(word)
+		r1, _ := markR1R2(rs)
+		assert.Equal(t, expect, string(step3(rs, r1)), word)
+	}
+}
+
+func TestPorterStep4(t *testing.T) {
+	for word, expect := range steps4 {
+		rs := []rune(word)
+		_, r2 := markR1R2(rs)
+		assert.Equal(t, expect, string(step4(rs, r2)), word)
+	}
+}
+
+func TestPorterStep5(t *testing.T) {
+	for word, expect := range steps5a {
+		rs := []rune(word)
+		r1, r2 := markR1R2(rs)
+		assert.Equal(t, expect, string(step5a(rs, r1, r2)), word)
+	}
+
+	for word, expect := range steps5b {
+		rs := []rune(word)
+		_, r2 := markR1R2(rs)
+		assert.Equal(t, expect, string(step5b(rs, r2)), word)
+	}
+}
+
+// testdata/voc.txt and testdata/output.txt are the vocabulary and output published
+// with Martin Porter's reference implementation, at
+// https://tartarus.org/martin/PorterStemmer/voc.txt and output.txt.
+func TestPorterCanonicalOutput(t *testing.T) {
+	if _, err := os.Stat("testdata/voc.txt"); err != nil {
+		t.Skip("testdata/voc.txt and testdata/output.txt are not in the tree")
+	}
+
+	words, stems := loadVoc("testdata/voc.txt", "testdata/output.txt")
+	assert.NotEmpty(t, words)
+
+	for i, word := range words {
+		assert.Equal(t, stems[i], Stem(word), word)
+		assert.Equal(t, stems[i], string(StemBytes([]byte(word))), word)
+		assert.Equal(t, stems[i], refStem(word), word)
+	}
+}
+
+// output.txt has the stems of the porter2 vocabulary in ../voc.txt, as given by
+// refStem. It is not canonical, but it covers the apostrophes, letters with
+// accents and upper case that the canonical vocabulary doesn't, and catches any
+// change to the stems the byte and rune paths give.
+func TestPorterVocOutput(t *testing.T) {
+	words, stems := loadVoc("../voc.txt", "output.txt")
+	assert.NotEmpty(t, words)
+
+	var dst []byte
+	for i, word := range words {
+		assert.Equal(t, stems[i], Stem(word), word)
+
+		dst = AppendStem(dst[:0], []byte(word))
+		assert.Equal(t, stems[i], string(dst), word)
+
+		assert.Equal(t, stems[i], string(StemBytes([]byte(word))), word)
+
+		// the rune path must agree with the byte path
+		if len(word) > 2 {
+			assert.Equal(t, stems[i], string(stem([]rune(word))), word)
+		}
+	}
+}
+
+func TestPorterNonASCII(t *testing.T) {
+	for word, expect := range map[string]string{
+		"":           "",
+		"Cafés":      "café",
+		"naïvely":    "naïv",
+		"RÉSUMÉS":    "résumé",
+		"jalapeños":  "jalapeño",
+		"Generously": "gener",
+	} {
+		assert.Equal(t, expect, Stem(word), word)
+		assert.Equal(t, expect, string(AppendStem(nil, []byte(word))), word)
+	}
+}
+
+func TestPorterRegistered(t *testing.T) {
+	s, err := porter2.Lookup("porter")
+	assert.NoError(t, err)
+	assert.Equal(t, Porter, s)
+	assert.Equal(t, "gener", s.Stem("generously"))
+	assert.Equal(t, "gener", string(s.AppendStem(nil, []byte("generously"))))
+}
+
+func TestPorterAppendStemAllocs(t *testing.T) {
+	bs, _ := loadVocBytes("../voc.txt", "output.txt")
+
+	dst := make([]byte, 0, 256)
+	allocs := testing.AllocsPerRun(10, func() {
+		for _, word := range bs {
+			dst = AppendStem(dst[:0], word)
+		}
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkPorterStem(b *testing.B) {
+	words, _ := loadVoc("../voc.txt", "output.txt")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range words {
+			Stem(word)
+		}
+	}
+}
+
+func BenchmarkPorterAppendStem(b *testing.B) {
+	bs, _ := loadVocBytes("../voc.txt", "output.txt")
+
+	dst := make([]byte, 0, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range bs {
+			dst = AppendStem(dst[:0], word)
+		}
+	}
+}
+
+// loadVoc reads the vocabulary and the expected output into memory, one word per line.
+func loadVoc(vocname, outname string) ([]string, []string) {
+	infile, err := os.Open(vocname)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer infile.Close()
+
+	outfile, err := os.Open(outname)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer outfile.Close()
+
+	inscan, outscan := bufio.NewScanner(infile), bufio.NewScanner(outfile)
+
+	var words, stems []string
+
+	for inscan.Scan() {
+		if !outscan.Scan() {
+			break
+		}
+
+		words = append(words, inscan.Text())
+		stems = append(stems, outscan.Text())
+	}
+
+	return words, stems
+}
+
+// loadVocBytes is loadVoc with the words as byte slices, for AppendStem and StemBytes.
+func loadVocBytes(vocname, outname string) ([][]byte, []string) {
+	words, stems := loadVoc(vocname, outname)
+
+	bs := make([][]byte, len(words))
+	for i, word := range words {
+		bs[i] = []byte(word)
+	}
+
+	return bs, stems
+}
@@ -0,0 +1,354 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter1
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// refStemmer is a line by line transliteration of Martin Porter's ANSI C
+// reference implementation, https://tartarus.org/martin/PorterStemmer/c.txt. It
+// measures m and matches suffixes with string comparisons, rather than with R1,
+// R2 and state machines, so the tests and FuzzPorterReference can check Stem
+// against it for any word, over and above the canonical output in testdata.
+//
+// b is the word, k0 and k are its first and last letters, and j is the end of
+// the stem once ends has matched a suffix, as in the C code.
+type refStemmer struct {
+	b        []rune
+	k, k0, j int
+}
+
+// refStem returns the stem of word, which must already be in lower case.
+func refStem(word string) string {
+	z := &refStemmer{b: []rune(word), k: len([]rune(word)) - 1}
+
+	// strings of length 1 or 2 don't go through the stemming process
+	if z.k <= z.k0+1 {
+		return word
+	}
+
+	z.step1ab()
+	if z.k > z.k0 {
+		z.step1c()
+		z.step2()
+		z.step3()
+		z.step4()
+		z.step5()
+	}
+
+	return string(z.b[:z.k+1])
+}
+
+// cons is true if b[i] is a consonant.
+func (z *refStemmer) cons(i int) bool {
+	switch z.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		if i == z.k0 {
+			return true
+		}
+		return !z.cons(i - 1)
+	}
+
+	return true
+}
+
+// m measures the number of consonant sequences between k0 and j, i.e., the m
+// of [C](VC){m}[V].
+func (z *refStemmer) m() int {
+	n, i := 0, z.k0
+
+	for {
+		if i > z.j {
+			return n
+		}
+		if !z.cons(i) {
+			break
+		}
+		i++
+	}
+	i++
+
+	for {
+		for {
+			if i > z.j {
+				return n
+			}
+			if z.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+		n++
+
+		for {
+			if i > z.j {
+				return n
+			}
+			if !z.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+	}
+}
+
+// vowelinstem is true if k0,...,j contains a vowel.
+func (z *refStemmer) vowelinstem() bool {
+	for i := z.k0; i <= z.j; i++ {
+		if !z.cons(i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// doublec is true if j,(j-1) contain a double consonant.
+func (z *refStemmer) doublec(j int) bool {
+	if j < z.k0+1 || z.b[j] != z.b[j-1] {
+		return false
+	}
+
+	return z.cons(j)
+}
+
+// cvc is true if i-2,i-1,i has the form consonant - vowel - consonant and also
+// if the second c is not w, x or y.
+func (z *refStemmer) cvc(i int) bool {
+	if i < z.k0+2 || !z.cons(i) || z.cons(i-1) || !z.cons(i-2) {
+		return false
+	}
+
+	switch z.b[i] {
+	case 'w', 'x', 'y':
+		return false
+	}
+
+	return true
+}
+
+// ends is true if k0,...,k ends with s, and sets j to the end of the stem.
+func (z *refStemmer) ends(s string) bool {
+	if len(s) > z.k-z.k0+1 || !strings.HasSuffix(string(z.b[z.k0:z.k+1]), s) {
+		return false
+	}
+
+	z.j = z.k - len(s)
+	return true
+}
+
+// setto sets (j+1),...,k to s, readjusting k.
+func (z *refStemmer) setto(s string) {
+	z.b = append(z.b[:z.j+1], []rune(s)...)
+	z.k = z.j + len(s)
+}
+
+func (z *refStemmer) r(s string) {
+	if z.m() > 0 {
+		z.setto(s)
+	}
+}
+
+// step1ab gets rid of plurals and -ed or -ing.
+func (z *refStemmer) step1ab() {
+	if z.b[z.k] == 's' {
+		switch {
+		case z.ends("sses"):
+			z.k -= 2
+		case z.ends("ies"):
+			z.setto("i")
+		case z.b[z.k-1] != 's':
+			z.k--
+		}
+	}
+
+	if z.ends("eed") {
+		if z.m() > 0 {
+			z.k--
+		}
+	} else if (z.ends("ed") || z.ends("ing")) && z.vowelinstem() {
+		z.k = z.j
+
+		switch {
+		case z.ends("at"):
+			z.setto("ate")
+		case z.ends("bl"):
+			z.setto("ble")
+		case z.ends("iz"):
+			z.setto("ize")
+		case z.doublec(z.k):
+			z.k--
+			switch z.b[z.k] {
+			case 'l', 's', 'z':
+				z.k++
+			}
+		default:
+			z.j = z.k
+			if z.m() == 1 && z.cvc(z.k) {
+				z.setto("e")
+			}
+		}
+	}
+}
+
+// step1c turns terminal y to i when there is another vowel in the stem.
+func (z *refStemmer) step1c() {
+	if z.ends("y") && z.vowelinstem() {
+		z.b[z.k] = 'i'
+	}
+}
+
+// refSuffixes lists the suffixes of step2 and step3 in the order the C code
+// tries them, by the letter it switches on, with what they're replaced by.
+type refSuffixes map[rune][][2]string
+
+var (
+	refStep2 = refSuffixes{
+		'a': {{"ational", "ate"}, {"tional", "tion"}},
+		'c': {{"enci", "ence"}, {"anci", "ance"}},
+		'e': {{"izer", "ize"}},
+		'l': {{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"}},
+		'o': {{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}},
+		's': {{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"}},
+		't': {{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"}},
+		'g': {{"logi", "log"}},
+	}
+
+	refStep3 = refSuffixes{
+		'e': {{"icate", "ic"}, {"ative", ""}, {"alize", "al"}},
+		'i': {{"iciti", "ic"}},
+		'l': {{"ical", "ic"}, {"ful", ""}},
+		's': {{"ness", ""}},
+	}
+
+	refStep4 = map[rune][]string{
+		'a': {"al"},
+		'c': {"ance", "ence"},
+		'e': {"er"},
+		'i': {"ic"},
+		'l': {"able", "ible"},
+		'n': {"ant", "ement", "ment", "ent"},
+		'o': {"ion", "ou"},
+		's': {"ism"},
+		't': {"ate", "iti"},
+		'u': {"ous"},
+		'v': {"ive"},
+		'z': {"ize"},
+	}
+)
+
+// replace replaces the first of the suffixes for r that the word ends with, if
+// the stem has m > 0.
+func (z *refStemmer) replace(suffixes refSuffixes, r rune) {
+	for _, s := range suffixes[r] {
+		if z.ends(s[0]) {
+			z.r(s[1])
+			return
+		}
+	}
+}
+
+// step2 maps double suffices to single ones, e.g., -ization to -ize.
+func (z *refStemmer) step2() {
+	z.replace(refStep2, z.b[z.k-1])
+}
+
+// step3 deals with -ic-, -full, -ness etc.
+func (z *refStemmer) step3() {
+	z.replace(refStep3, z.b[z.k])
+}
+
+// step4 takes off -ant, -ence etc., in context <c>vcvc<v>.
+func (z *refStemmer) step4() {
+	for _, s := range refStep4[z.b[z.k-1]] {
+		if !z.ends(s) {
+			continue
+		}
+
+		// ion is only removed after s or t
+		if s == "ion" && !(z.j >= z.k0 && (z.b[z.j] == 's' || z.b[z.j] == 't')) {
+			continue
+		}
+
+		if z.m() > 1 {
+			z.k = z.j
+		}
+		return
+	}
+}
+
+// step5 removes a final -e if m > 1, and changes -ll to -l if m > 1.
+func (z *refStemmer) step5() {
+	z.j = z.k
+
+	if z.b[z.k] == 'e' {
+		if a := z.m(); a > 1 || a == 1 && !z.cvc(z.k-1) {
+			z.k--
+		}
+	}
+
+	if z.b[z.k] == 'l' && z.doublec(z.k) && z.m() > 1 {
+		z.k--
+	}
+}
+
+func TestPorterReference(t *testing.T) {
+	words, stems := loadVoc("../voc.txt", "output.txt")
+
+	for i, word := range words {
+		assert.Equal(t, refStem(strings.ToLower(word)), stems[i], word)
+	}
+
+	// the departures of the reference implementation from the paper
+	for word, expect := range map[string]string{
+		"as":          "as",
+		"is":          "is",
+		"archaeology": "archaeolog",
+		"analogies":   "analog",
+		"possibly":    "possibl",
+		"feasibly":    "feasibl",
+	} {
+		assert.Equal(t, expect, refStem(word), word)
+		assert.Equal(t, expect, Stem(word), word)
+	}
+}
+
+func FuzzPorterReference(f *testing.F) {
+	words, _ := loadVoc("../voc.txt", "output.txt")
+	for _, word := range words {
+		f.Add(word)
+	}
+
+	f.Fuzz(func(t *testing.T, word string) {
+		// the reference only knows about lower case ASCII letters
+		for i := 0; i < len(word); i++ {
+			if word[i] < 'a' || word[i] > 'z' {
+				return
+			}
+		}
+
+		if stem, expect := Stem(word), refStem(word); stem != expect {
+			t.Errorf("Stem(%q) = %q, reference %q", word, stem, expect)
+		}
+	})
+}
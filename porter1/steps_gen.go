@@ -0,0 +1,1360 @@
+// Code generated by suffixfsm from step1a.txt, step1b.txt, step2.txt, step3.txt, step4.txt. DO NOT EDIT.
+
+package porter1
+
+// Search for the longest suffix among the suffixes, and perform the action indicated.
+//
+//	sses : replace by ss
+//	 ies : replace by i
+//	  ss : do nothing
+//	   s : delete
+func step1a[T letter](rs []T) []T {
+	l := len(rs)
+	m, f := suffix1a(rs)
+
+	switch f {
+	case 1:
+		// s - final
+		rs = rs[:l-m]
+
+	case 4, 5:
+		// sses - final
+		// ies - final
+		rs = rs[:l-2]
+
+	case 6:
+		// ss - final
+		// do nothing
+	}
+
+	return rs
+}
+
+// suffix1a runs the state machine for step1a over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix1a[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case 's':
+				s = 1
+				m = 1
+				f = 1
+				// s - final
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 'e':
+				s = 2
+			case 's':
+				s = 6
+				m = 2
+				f = 6
+				// ss - final
+			default:
+				break loop
+			}
+		case 2:
+			switch r {
+			case 's':
+				s = 3
+			case 'i':
+				s = 5
+				m = 3
+				f = 5
+				// ies - final
+			default:
+				break loop
+			}
+		case 3:
+			switch r {
+			case 's':
+				s = 4
+				m = 4
+				f = 4
+				// sses - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
+
+// Search for the longest suffix among the suffixes, and perform the action indicated.
+//
+//	eed -> replace by ee if in R1
+//	 ed -> see note below
+//	ing -> see note below
+//
+// Note: delete if the preceding word part contains a vowel, and after the deletion:
+//
+//	if the word ends at, bl or iz add e (so conflat -> conflate), or
+//	if the word ends with a double remove the last letter (so hopp -> hop), or
+//	if R1 is null and the word ends with a short syllable, add e (so fil -> file)
+func step1b[T letter](rs []T, r1 int) []T {
+	l := len(rs)
+	m, f := suffix1b(rs)
+
+	switch f {
+	case 2, 6:
+		// ed - final
+		// ing - final
+		if hasVowel(rs[:l-m]) {
+			rs = rs[:l-m]
+			rs = fixEnding(rs, r1)
+		}
+
+	case 3:
+		// eed - final
+		if l-m >= r1 {
+			rs = rs[:l-1]
+		}
+	}
+
+	return rs
+}
+
+// suffix1b runs the state machine for step1b over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix1b[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case 'd':
+				s = 1
+			case 'g':
+				s = 4
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 'e':
+				s = 2
+				m = 2
+				f = 2
+				// ed - final
+			default:
+				break loop
+			}
+		case 2:
+			switch r {
+			case 'e':
+				s = 3
+				m = 3
+				f = 3
+				// eed - final
+			default:
+				break loop
+			}
+		case 4:
+			switch r {
+			case 'n':
+				s = 5
+			default:
+				break loop
+			}
+		case 5:
+			switch r {
+			case 'i':
+				s = 6
+				m = 3
+				f = 6
+				// ing - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
+
+// Search for the longest among the following suffixes, and, if found and in R1,
+// perform the action indicated.
+//
+//  1. tional -> replace by tion
+//  2. enci -> replace by ence
+//  3. anci -> replace by ance
+//  4. bli -> replace by ble
+//  5. entli -> replace by ent
+//  6. eli -> replace by e
+//  7. izer -> replace by ize
+//  8. ization -> replace by ize
+//  9. ational -> replace by ate
+//  10. ation -> replace by ate
+//  11. ator -> replace by ate
+//  12. alli -> replace by al
+//  13. alism -> replace by al
+//  14. aliti -> replace by al
+//  15. fulness -> replace by ful
+//  16. ousli -> replace by ous
+//  17. ousness -> replace by ous
+//  18. iveness -> replace by ive
+//  19. iviti -> replace by ive
+//  20. biliti -> replace by ble
+//  21. logi -> replace by log
+//
+// bli and logi depart from the paper, which has abli -> able and no logi, the
+// same way Martin Porter's reference implementation does.
+func step2[T letter](rs []T, r1 int) []T {
+	l := len(rs)
+	m, f := suffix2(rs)
+
+	switch f {
+	case 6, 16, 17, 34, 53:
+		// tional - final
+		// entli - final
+		// eli - final
+		// alli - final
+		// ousli - final
+		if l-m >= r1 {
+			rs = rs[:l-2]
+		}
+
+	case 10, 11, 13:
+		// enci - final
+		// anci - final
+		// bli - final
+		if l-m >= r1 {
+			rs = append(rs[:l-1], 'e')
+		}
+
+	case 21, 66:
+		// izer - final
+		// logi - final
+		if l-m >= r1 {
+			rs = rs[:l-1]
+		}
+
+	case 26, 61:
+		// ation - final
+		// iviti - final
+		if l-m >= r1 {
+			rs = append(rs[:l-3], 'e')
+		}
+
+	case 28, 29:
+		// ization - final
+		// ational - final
+		if l-m >= r1 {
+			rs = append(rs[:l-5], 'e')
+		}
+
+	case 32:
+		// ator - final
+		if l-m >= r1 {
+			rs = append(rs[:l-2], 'e')
+		}
+
+	case 39, 43:
+		// alism - final
+		// aliti - final
+		if l-m >= r1 {
+			rs = rs[:l-3]
+		}
+
+	case 50, 56, 59:
+		// fulness - final
+		// ousness - final
+		// iveness - final
+		if l-m >= r1 {
+			rs = rs[:l-4]
+		}
+
+	case 63:
+		// biliti - final
+		if l-m >= r1 {
+			rs = append(rs[:l-5], 'l', 'e')
+		}
+	}
+
+	return rs
+}
+
+// suffix2 runs the state machine for step2 over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix2[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case 'l':
+				s = 1
+			case 'i':
+				s = 7
+			case 'r':
+				s = 18
+			case 'n':
+				s = 22
+			case 'm':
+				s = 35
+			case 's':
+				s = 44
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 'a':
+				s = 2
+			default:
+				break loop
+			}
+		case 2:
+			switch r {
+			case 'n':
+				s = 3
+			default:
+				break loop
+			}
+		case 3:
+			switch r {
+			case 'o':
+				s = 4
+			default:
+				break loop
+			}
+		case 4:
+			switch r {
+			case 'i':
+				s = 5
+			default:
+				break loop
+			}
+		case 5:
+			switch r {
+			case 't':
+				s = 6
+				m = 6
+				f = 6
+				// tional - final
+			default:
+				break loop
+			}
+		case 6:
+			switch r {
+			case 'a':
+				s = 29
+				m = 7
+				f = 29
+				// ational - final
+			default:
+				break loop
+			}
+		case 7:
+			switch r {
+			case 'c':
+				s = 8
+			case 'l':
+				s = 12
+			case 't':
+				s = 40
+			case 'g':
+				s = 64
+			default:
+				break loop
+			}
+		case 8:
+			switch r {
+			case 'n':
+				s = 9
+			default:
+				break loop
+			}
+		case 9:
+			switch r {
+			case 'e':
+				s = 10
+				m = 4
+				f = 10
+				// enci - final
+			case 'a':
+				s = 11
+				m = 4
+				f = 11
+				// anci - final
+			default:
+				break loop
+			}
+		case 12:
+			switch r {
+			case 'b':
+				s = 13
+				m = 3
+				f = 13
+				// bli - final
+			case 't':
+				s = 14
+			case 'e':
+				s = 17
+				m = 3
+				f = 17
+				// eli - final
+			case 'l':
+				s = 33
+			case 's':
+				s = 51
+			default:
+				break loop
+			}
+		case 14:
+			switch r {
+			case 'n':
+				s = 15
+			default:
+				break loop
+			}
+		case 15:
+			switch r {
+			case 'e':
+				s = 16
+				m = 5
+				f = 16
+				// entli - final
+			default:
+				break loop
+			}
+		case 18:
+			switch r {
+			case 'e':
+				s = 19
+			case 'o':
+				s = 30
+			default:
+				break loop
+			}
+		case 19:
+			switch r {
+			case 'z':
+				s = 20
+			default:
+				break loop
+			}
+		case 20:
+			switch r {
+			case 'i':
+				s = 21
+				m = 4
+				f = 21
+				// izer - final
+			default:
+				break loop
+			}
+		case 22:
+			switch r {
+			case 'o':
+				s = 23
+			default:
+				break loop
+			}
+		case 23:
+			switch r {
+			case 'i':
+				s = 24
+			default:
+				break loop
+			}
+		case 24:
+			switch r {
+			case 't':
+				s = 25
+			default:
+				break loop
+			}
+		case 25:
+			switch r {
+			case 'a':
+				s = 26
+				m = 5
+				f = 26
+				// ation - final
+			default:
+				break loop
+			}
+		case 26:
+			switch r {
+			case 'z':
+				s = 27
+			default:
+				break loop
+			}
+		case 27:
+			switch r {
+			case 'i':
+				s = 28
+				m = 7
+				f = 28
+				// ization - final
+			default:
+				break loop
+			}
+		case 30:
+			switch r {
+			case 't':
+				s = 31
+			default:
+				break loop
+			}
+		case 31:
+			switch r {
+			case 'a':
+				s = 32
+				m = 4
+				f = 32
+				// ator - final
+			default:
+				break loop
+			}
+		case 33:
+			switch r {
+			case 'a':
+				s = 34
+				m = 4
+				f = 34
+				// alli - final
+			default:
+				break loop
+			}
+		case 35:
+			switch r {
+			case 's':
+				s = 36
+			default:
+				break loop
+			}
+		case 36:
+			switch r {
+			case 'i':
+				s = 37
+			default:
+				break loop
+			}
+		case 37:
+			switch r {
+			case 'l':
+				s = 38
+			default:
+				break loop
+			}
+		case 38:
+			switch r {
+			case 'a':
+				s = 39
+				m = 5
+				f = 39
+				// alism - final
+			default:
+				break loop
+			}
+		case 40:
+			switch r {
+			case 'i':
+				s = 41
+			default:
+				break loop
+			}
+		case 41:
+			switch r {
+			case 'l':
+				s = 42
+			case 'v':
+				s = 60
+			default:
+				break loop
+			}
+		case 42:
+			switch r {
+			case 'a':
+				s = 43
+				m = 5
+				f = 43
+				// aliti - final
+			case 'i':
+				s = 62
+			default:
+				break loop
+			}
+		case 44:
+			switch r {
+			case 's':
+				s = 45
+			default:
+				break loop
+			}
+		case 45:
+			switch r {
+			case 'e':
+				s = 46
+			default:
+				break loop
+			}
+		case 46:
+			switch r {
+			case 'n':
+				s = 47
+			default:
+				break loop
+			}
+		case 47:
+			switch r {
+			case 'l':
+				s = 48
+			case 's':
+				s = 54
+			case 'e':
+				s = 57
+			default:
+				break loop
+			}
+		case 48:
+			switch r {
+			case 'u':
+				s = 49
+			default:
+				break loop
+			}
+		case 49:
+			switch r {
+			case 'f':
+				s = 50
+				m = 7
+				f = 50
+				// fulness - final
+			default:
+				break loop
+			}
+		case 51:
+			switch r {
+			case 'u':
+				s = 52
+			default:
+				break loop
+			}
+		case 52:
+			switch r {
+			case 'o':
+				s = 53
+				m = 5
+				f = 53
+				// ousli - final
+			default:
+				break loop
+			}
+		case 54:
+			switch r {
+			case 'u':
+				s = 55
+			default:
+				break loop
+			}
+		case 55:
+			switch r {
+			case 'o':
+				s = 56
+				m = 7
+				f = 56
+				// ousness - final
+			default:
+				break loop
+			}
+		case 57:
+			switch r {
+			case 'v':
+				s = 58
+			default:
+				break loop
+			}
+		case 58:
+			switch r {
+			case 'i':
+				s = 59
+				m = 7
+				f = 59
+				// iveness - final
+			default:
+				break loop
+			}
+		case 60:
+			switch r {
+			case 'i':
+				s = 61
+				m = 5
+				f = 61
+				// iviti - final
+			default:
+				break loop
+			}
+		case 62:
+			switch r {
+			case 'b':
+				s = 63
+				m = 6
+				f = 63
+				// biliti - final
+			default:
+				break loop
+			}
+		case 64:
+			switch r {
+			case 'o':
+				s = 65
+			default:
+				break loop
+			}
+		case 65:
+			switch r {
+			case 'l':
+				s = 66
+				m = 4
+				f = 66
+				// logi - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
+
+// Search for the longest among the following suffixes, and, if found and in R1,
+// perform the action indicated.
+//
+//	alize -> replace by al
+//	icate -> replace by ic
+//	iciti -> replace by ic
+//	 ical -> replace by ic
+//	ative -> delete
+//	  ful -> delete
+//	 ness -> delete
+func step3[T letter](rs []T, r1 int) []T {
+	l := len(rs)
+	m, f := suffix3(rs)
+
+	switch f {
+	case 5, 9, 14:
+		// alize - final
+		// icate - final
+		// iciti - final
+		if l-m >= r1 {
+			rs = rs[:l-3]
+		}
+
+	case 18:
+		// ical - final
+		if l-m >= r1 {
+			rs = rs[:l-2]
+		}
+
+	case 22, 24, 28:
+		// ative - final
+		// ful - final
+		// ness - final
+		if l-m >= r1 {
+			rs = rs[:l-m]
+		}
+	}
+
+	return rs
+}
+
+// suffix3 runs the state machine for step3 over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix3[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case 'e':
+				s = 1
+			case 'i':
+				s = 10
+			case 'l':
+				s = 15
+			case 's':
+				s = 25
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 'z':
+				s = 2
+			case 't':
+				s = 6
+			case 'v':
+				s = 19
+			default:
+				break loop
+			}
+		case 2:
+			switch r {
+			case 'i':
+				s = 3
+			default:
+				break loop
+			}
+		case 3:
+			switch r {
+			case 'l':
+				s = 4
+			default:
+				break loop
+			}
+		case 4:
+			switch r {
+			case 'a':
+				s = 5
+				m = 5
+				f = 5
+				// alize - final
+			default:
+				break loop
+			}
+		case 6:
+			switch r {
+			case 'a':
+				s = 7
+			default:
+				break loop
+			}
+		case 7:
+			switch r {
+			case 'c':
+				s = 8
+			default:
+				break loop
+			}
+		case 8:
+			switch r {
+			case 'i':
+				s = 9
+				m = 5
+				f = 9
+				// icate - final
+			default:
+				break loop
+			}
+		case 10:
+			switch r {
+			case 't':
+				s = 11
+			default:
+				break loop
+			}
+		case 11:
+			switch r {
+			case 'i':
+				s = 12
+			default:
+				break loop
+			}
+		case 12:
+			switch r {
+			case 'c':
+				s = 13
+			default:
+				break loop
+			}
+		case 13:
+			switch r {
+			case 'i':
+				s = 14
+				m = 5
+				f = 14
+				// iciti - final
+			default:
+				break loop
+			}
+		case 15:
+			switch r {
+			case 'a':
+				s = 16
+			case 'u':
+				s = 23
+			default:
+				break loop
+			}
+		case 16:
+			switch r {
+			case 'c':
+				s = 17
+			default:
+				break loop
+			}
+		case 17:
+			switch r {
+			case 'i':
+				s = 18
+				m = 4
+				f = 18
+				// ical - final
+			default:
+				break loop
+			}
+		case 19:
+			switch r {
+			case 'i':
+				s = 20
+			default:
+				break loop
+			}
+		case 20:
+			switch r {
+			case 't':
+				s = 21
+			default:
+				break loop
+			}
+		case 21:
+			switch r {
+			case 'a':
+				s = 22
+				m = 5
+				f = 22
+				// ative - final
+			default:
+				break loop
+			}
+		case 23:
+			switch r {
+			case 'f':
+				s = 24
+				m = 3
+				f = 24
+				// ful - final
+			default:
+				break loop
+			}
+		case 25:
+			switch r {
+			case 's':
+				s = 26
+			default:
+				break loop
+			}
+		case 26:
+			switch r {
+			case 'e':
+				s = 27
+			default:
+				break loop
+			}
+		case 27:
+			switch r {
+			case 'n':
+				s = 28
+				m = 4
+				f = 28
+				// ness - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
+
+// Search for the longest among the following suffixes, and, if found and in R2,
+// perform the action indicated.
+//
+// al ance ence er ic able ible ant ement ment ent ou ism ate iti ous ive ize
+//
+//	delete
+//
+// ion
+//
+//	delete if preceded by s or t
+func step4[T letter](rs []T, r2 int) []T {
+	l := len(rs)
+	m, f := suffix4(rs)
+
+	switch f {
+	case 2, 6, 7, 9, 11, 14, 15, 18, 19, 20, 21, 26, 29, 31, 34, 37, 39, 41:
+		// al - final
+		// ance - final
+		// ence - final
+		// er - final
+		// ic - final
+		// able - final
+		// ible - final
+		// ant - final
+		// ent - final
+		// ment - final
+		// ement - final
+		// ou - final
+		// ism - final
+		// ate - final
+		// iti - final
+		// ous - final
+		// ive - final
+		// ize - final
+		if l-m >= r2 {
+			rs = rs[:l-m]
+		}
+
+	case 24:
+		// ion - final
+		if l-m >= r2 && l > m {
+			switch rs[l-m-1] {
+			case 's', 't':
+				rs = rs[:l-m]
+			}
+		}
+	}
+
+	return rs
+}
+
+// suffix4 runs the state machine for step4 over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix4[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case 'l':
+				s = 1
+			case 'e':
+				s = 3
+			case 'r':
+				s = 8
+			case 'c':
+				s = 10
+			case 't':
+				s = 16
+			case 'n':
+				s = 22
+			case 'u':
+				s = 25
+			case 'm':
+				s = 27
+			case 'i':
+				s = 32
+			case 's':
+				s = 35
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 'a':
+				s = 2
+				m = 2
+				f = 2
+				// al - final
+			default:
+				break loop
+			}
+		case 3:
+			switch r {
+			case 'c':
+				s = 4
+			case 'l':
+				s = 12
+			case 't':
+				s = 30
+			case 'v':
+				s = 38
+			case 'z':
+				s = 40
+			default:
+				break loop
+			}
+		case 4:
+			switch r {
+			case 'n':
+				s = 5
+			default:
+				break loop
+			}
+		case 5:
+			switch r {
+			case 'a':
+				s = 6
+				m = 4
+				f = 6
+				// ance - final
+			case 'e':
+				s = 7
+				m = 4
+				f = 7
+				// ence - final
+			default:
+				break loop
+			}
+		case 8:
+			switch r {
+			case 'e':
+				s = 9
+				m = 2
+				f = 9
+				// er - final
+			default:
+				break loop
+			}
+		case 10:
+			switch r {
+			case 'i':
+				s = 11
+				m = 2
+				f = 11
+				// ic - final
+			default:
+				break loop
+			}
+		case 12:
+			switch r {
+			case 'b':
+				s = 13
+			default:
+				break loop
+			}
+		case 13:
+			switch r {
+			case 'a':
+				s = 14
+				m = 4
+				f = 14
+				// able - final
+			case 'i':
+				s = 15
+				m = 4
+				f = 15
+				// ible - final
+			default:
+				break loop
+			}
+		case 16:
+			switch r {
+			case 'n':
+				s = 17
+			default:
+				break loop
+			}
+		case 17:
+			switch r {
+			case 'a':
+				s = 18
+				m = 3
+				f = 18
+				// ant - final
+			case 'e':
+				s = 19
+				m = 3
+				f = 19
+				// ent - final
+			default:
+				break loop
+			}
+		case 19:
+			switch r {
+			case 'm':
+				s = 20
+				m = 4
+				f = 20
+				// ment - final
+			default:
+				break loop
+			}
+		case 20:
+			switch r {
+			case 'e':
+				s = 21
+				m = 5
+				f = 21
+				// ement - final
+			default:
+				break loop
+			}
+		case 22:
+			switch r {
+			case 'o':
+				s = 23
+			default:
+				break loop
+			}
+		case 23:
+			switch r {
+			case 'i':
+				s = 24
+				m = 3
+				f = 24
+				// ion - final
+			default:
+				break loop
+			}
+		case 25:
+			switch r {
+			case 'o':
+				s = 26
+				m = 2
+				f = 26
+				// ou - final
+			default:
+				break loop
+			}
+		case 27:
+			switch r {
+			case 's':
+				s = 28
+			default:
+				break loop
+			}
+		case 28:
+			switch r {
+			case 'i':
+				s = 29
+				m = 3
+				f = 29
+				// ism - final
+			default:
+				break loop
+			}
+		case 30:
+			switch r {
+			case 'a':
+				s = 31
+				m = 3
+				f = 31
+				// ate - final
+			default:
+				break loop
+			}
+		case 32:
+			switch r {
+			case 't':
+				s = 33
+			default:
+				break loop
+			}
+		case 33:
+			switch r {
+			case 'i':
+				s = 34
+				m = 3
+				f = 34
+				// iti - final
+			default:
+				break loop
+			}
+		case 35:
+			switch r {
+			case 'u':
+				s = 36
+			default:
+				break loop
+			}
+		case 36:
+			switch r {
+			case 'o':
+				s = 37
+				m = 3
+				f = 37
+				// ous - final
+			default:
+				break loop
+			}
+		case 38:
+			switch r {
+			case 'i':
+				s = 39
+				m = 3
+				f = 39
+				// ive - final
+			default:
+				break loop
+			}
+		case 40:
+			switch r {
+			case 'i':
+				s = 41
+				m = 3
+				f = 41
+				// ize - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
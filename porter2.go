@@ -40,50 +40,165 @@
 // to generate most of the state machines. The tool basically takes a file of
 // suffixes, creates a tree, then unrolls the tree by dumping each of the nodes.
 //
-// You can run the tool by `go run suffixfsm.go <filename>`.
+// You can run the tool by `go run ./cmd/suffixfsm <filename>`.
 package porter2
 
-import "unicode"
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/surgebase/porter2/internal/letters"
+)
+
+// The suffix state machines, and the steps that use them, are generated into
+// steps_gen.go from the suffix specs in cmd/suffixfsm/step*.txt. Run go generate
+// after changing the specs. The minimized, table-driven versions of the same
+// state machines are generated for the tests, which check they agree with the
+// switch-based ones and benchmark the two against each other.
+//go:generate go run ./cmd/suffixfsm -pkg porter2 -o steps_gen.go cmd/suffixfsm/step*.txt
+//go:generate go run ./cmd/suffixfsm -minimize -table -tag Table -pkg porter2 -o steps_table_gen_test.go cmd/suffixfsm/step*.txt
+
+// letter is the element type the state machines, including the generated ones,
+// operate on.
+type letter = letters.Letter
 
 // Stem takes a string and returns the stemmed version based on the Porter2 algorithm.
 func Stem(s string) string {
-	// If the word has two letters or less, leave it as it is.
-	if len(s) <= 2 {
+	return stemString(s, nil)
+}
+
+// StemBytes stems word in place and returns the stemmed version, which shares
+// the underlying array with word. The original contents of word are overwritten.
+// It does not allocate unless word is longer than 64 runes, or the lower case
+// form of word is longer than cap(word).
+func StemBytes(word []byte) []byte {
+	return AppendStem(word[:0], word)
+}
+
+// AppendStem appends the stemmed version of word to dst and returns the extended
+// buffer. word is not modified, unless it shares its underlying array with dst as
+// in StemBytes. It does not allocate unless word is longer than 64 runes, or dst
+// does not have enough capacity to hold the result.
+func AppendStem(dst, word []byte) []byte {
+	return appendStem(dst, word, nil)
+}
+
+// stemString is Stem for the stemmer e, which is nil for the plain algorithm.
+func stemString(s string, e *english) string {
+	fold := e != nil && e.fold
+
+	// If the word has two letters or less, or is protected, leave it as it is.
+	// Words that are folded are counted once their accents are removed, in stem.
+	if !fold && letters.IsShort(s) || isProtected(e, s) {
 		return s
 	}
 
+	// ASCII words are stemmed as lower case bytes, with no decoding needed
+	if letters.IsASCII(s) {
+		var buf [letters.StackRunes]byte
+		return string(stem(letters.ToLowerASCII(append(buf[:0], s...)), e))
+	}
+
 	// Convert s from string to lower case rune slice
-	rs := []rune(s)
-	for i, r := range rs {
-		rs[i] = unicode.ToLower(r)
+	rs := make([]rune, 0, len(s))
+	for _, r := range s {
+		rs = appendLower(rs, r, fold)
 	}
 
+	return string(stem(rs, e))
+}
+
+// appendStem is AppendStem for the stemmer e, which is nil for the plain algorithm.
+func appendStem(dst, word []byte, e *english) []byte {
+	fold := e != nil && e.fold
+
+	// If the word has two letters or less, or is protected, leave it as it is.
+	// Words that are folded are counted once their accents are removed, in stem.
+	if !fold && letters.IsShort(word) || isProtected(e, word) {
+		return append(dst, word...)
+	}
+
+	// ASCII words are copied to the end of dst and stemmed right there
+	if letters.IsASCII(word) {
+		n := len(dst)
+		dst = append(dst, word...)
+		return append(dst[:n], stem(letters.ToLowerASCII(dst[n:]), e)...)
+	}
+
+	// Decode word into a lower case rune slice backed by the stack
+	var buf [letters.StackRunes]rune
+	rs := buf[:0]
+	for i := 0; i < len(word); {
+		r, n := utf8.DecodeRune(word[i:])
+		rs = appendLower(rs, r, fold)
+		i += n
+	}
+
+	return letters.AppendRunes(dst, stem(rs, e))
+}
+
+// stem runs the Porter2 algorithm over rs, which must already be in lower case.
+// rs is modified in place, and the returned slice shares its underlying array.
+// The user supplied exceptions in e, if any, are consulted along with the
+// built-in ones.
+func stem[T letter](rs []T, e *english) []T {
 	var ex bool
 
+	// Words of two letters or less only get this far if their accents were
+	// folded, which may have left fewer letters, or none at all. They're left
+	// lower cased and folded, but otherwise as they are.
+	if len(rs) <= 2 {
+		return rs
+	}
+
+	// user supplied stems take precedence over the exception1 word list
+	if e != nil && e.stems != nil {
+		if s, ok := lookup(e.stems, rs); ok {
+			return appendString(rs[:0], s)
+		}
+	}
+
 	// exception1 word list
 	if rs, ex = exception1(rs); ex {
-		return string(rs)
+		return rs
 	}
 
 	rs = preclude(rs)
 
+	// nothing is left once the initial ' is removed
+	if len(rs) == 0 {
+		return rs
+	}
+
 	r1, r2 := markR1R2(rs)
 
-	rs = step1a(step0(rs))
+	rs = step0(rs)
+
+	// user supplied invariants are checked both before and after step1a, so
+	// words that step1a would change are left as they are too
+	if invariant(e, rs) {
+		return postlude(rs)
+	}
+
+	rs = step1a(rs)
 
-	if exception2(rs) {
-		return string(rs)
+	if exception2(rs) || invariant(e, rs) {
+		return postlude(rs)
 	}
 
-	return string(postlude(step5(step4(step3(step2(step1c(step1b(rs, r1)), r1), r1, r2), r2), r1, r2)))
+	return postlude(step5(step4(step3(step2(step1c(step1b(rs, r1)), r1), r1, r2), r2), r1, r2))
 }
 
 // Remove initial ', if present. Then set initial y, or y after a vowel, to Y.
-func preclude(rs []rune) []rune {
-	if rs[0] == '\'' {
+func preclude[T letter](rs []T) []T {
+	if len(rs) > 0 && rs[0] == '\'' {
 		rs = rs[1:]
 	}
 
+	if len(rs) == 0 {
+		return rs
+	}
+
 	if rs[0] == 'y' {
 		rs[0] = 'Y'
 	}
@@ -106,7 +221,11 @@ func preclude(rs []rune) []rune {
 // null region at the end of the word if there is no such non-vowel.
 //
 // If the words begins gener, commun or arsen, set R1 to be the remainder of the word.
-func markR1R2(rs []rune) (int, int) {
+func markR1R2[T letter](rs []T) (int, int) {
+	if len(rs) == 0 {
+		return 0, 0
+	}
+
 	r1 := -1
 
 	switch rs[0] {
@@ -133,7 +252,7 @@ func markR1R2(rs []rune) (int, int) {
 	return r1, r1 + markRegion(rs[r1:])
 }
 
-func markRegion(rs []rune) int {
+func markRegion[T letter](rs []T) int {
 	if len(rs) == 0 {
 		return 0
 	}
@@ -146,397 +265,9 @@ func markRegion(rs []rune) int {
 	return len(rs)
 }
 
-// Search for the longest among the suffixes, and remove if found.
-// '
-// 's
-// 's'
-func step0(rs []rune) []rune {
-	var (
-		l int  = len(rs) // string length
-		m int            // suffix length
-		s int            // state
-		f int            // end state of longgest suffix
-		r rune           // current rune
-	)
-
-loop:
-	for i := 0; i < l; i++ {
-		r = rs[l-i-1]
-
-		switch s {
-
-		case 0:
-			switch r {
-			case '\'':
-				s = 1
-				m = 1
-				f = 1
-				// ' - final
-			case 's':
-				s = 2
-			default:
-				break loop
-			}
-		case 1:
-			switch r {
-			case 's':
-				s = 4
-			default:
-				break loop
-			}
-		case 2:
-			switch r {
-			case '\'':
-				s = 3
-				m = 2
-				f = 3
-				// 's - final
-			default:
-				break loop
-			}
-		case 4:
-			switch r {
-			case '\'':
-				s = 5
-				m = 3
-				f = 5
-				// 's' - final
-			default:
-				break loop
-			}
-		default:
-			break loop
-		}
-	}
-
-	switch f {
-	case 1, 3, 5:
-		rs = rs[:l-m]
-	}
-
-	return rs
-}
-
-// Search for the longest suffix among the suffixes, and perform the action indicated.
-//  sses : replace by ss
-//   ied : replace by i if preceded by more than one letter, otherwise by ie (so ties -> tie, cries -> cri)
-//   ies : replace by i if preceded by more than one letter, otherwise by ie (so ties -> tie, cries -> cri)
-//     s : delete if the preceding word part contains a vowel not immediately before the s (so gas and this retain the s, gaps and kiwis lose it)
-//    us : do nothing
-//    ss : do nothing
-func step1a(rs []rune) []rune {
-	var (
-		l int  = len(rs) // string length
-		m int            // suffix length
-		s int            // state
-		f int            // end state of longgest suffix
-		r rune           // current rune
-	)
-
-loop:
-	for i := 0; i < l; i++ {
-		r = rs[l-i-1]
-
-		switch s {
-
-		case 0:
-			switch r {
-			case 's':
-				s = 1
-				m = 1
-				f = 1
-				// s - final
-			case 'd':
-				s = 5
-			default:
-				break loop
-			}
-		case 1:
-			switch r {
-			case 'e':
-				s = 2
-			case 'u':
-				s = 9
-				m = 2
-				f = 9
-				// us - final
-			case 's':
-				s = 10
-				m = 2
-				f = 10
-				// ss - final
-			default:
-				break loop
-			}
-		case 2:
-			switch r {
-			case 's':
-				s = 3
-			case 'i':
-				s = 8
-				m = 3
-				f = 8
-				// ies - final
-			default:
-				break loop
-			}
-		case 3:
-			switch r {
-			case 's':
-				s = 4
-				m = 4
-				f = 4
-				// sses - final
-			default:
-				break loop
-			}
-		case 5:
-			switch r {
-			case 'e':
-				s = 6
-			default:
-				break loop
-			}
-		case 6:
-			switch r {
-			case 'i':
-				s = 7
-				m = 3
-				f = 7
-				// ied - final
-			default:
-				break loop
-			}
-		default:
-			break loop
-		}
-	}
-
-	switch f {
-	case 1:
-		// s - final
-		if l > 2 && hasVowel(rs[:l-2]) {
-			rs = rs[:l-1]
-		}
-
-	case 4:
-		// sses - final
-		rs = rs[:l-2]
-
-	case 7, 8:
-		// ied - final
-		// ies - final
-		// if there's at least 5 runes, then replace by i, otherwise by ie
-		// so ties -> tie, cries -> cri
-		rs = rs[:l-m]
-		if l >= 5 {
-			rs = append(rs, 'i')
-		} else {
-			rs = append(rs, []rune("ie")...)
-		}
-
-	case 9, 10:
-		// us - final
-		// ss - final
-		// do nothing
-	}
-
-	return rs
-}
-
-// Search for the longest suffix among the suffixes, and perform the action indicated.
-// 1. ingly -> see note below
-// 2. eedly -> replace by ee if in R1
-// 3.  edly -> see note below
-// 4.   ing -> see note below
-// 5.   eed -> replace by ee if in R1
-// 6.    ed -> see note below
-//
-// Note: delete if the preceding word part contains a vowel, and after the deletion:
-//       if the word ends at, bl or iz add e (so luxuriat -> luxuriate), or
-//       if the word ends with a double remove the last letter (so hopp -> hop), or
-//       if the word is short, add e (so hop -> hope)
-func step1b(rs []rune, r1 int) []rune {
-	var (
-		l int  = len(rs) // string length
-		m int            // suffix length
-		s int            // state
-		f int            // end state of longgest suffix
-		r rune           // current rune
-	)
-
-loop:
-	for i := 0; i < l; i++ {
-		r = rs[l-i-1]
-
-		switch s {
-
-		case 0:
-			switch r {
-			case 'y':
-				s = 1
-			case 'g':
-				s = 9
-			case 'd':
-				s = 12
-			default:
-				break loop
-			}
-		case 1:
-			switch r {
-			case 'l':
-				s = 2
-			default:
-				break loop
-			}
-		case 2:
-			switch r {
-			case 'g':
-				s = 3
-			case 'd':
-				s = 6
-			default:
-				break loop
-			}
-		case 3:
-			switch r {
-			case 'n':
-				s = 4
-			default:
-				break loop
-			}
-		case 4:
-			switch r {
-			case 'i':
-				s = 5
-				m = 5
-				f = 5
-				// ingly - final
-			default:
-				break loop
-			}
-		case 6:
-			switch r {
-			case 'e':
-				s = 7
-				m = 4
-				f = 7
-				// edly - final
-			default:
-				break loop
-			}
-		case 7:
-			switch r {
-			case 'e':
-				s = 8
-				m = 5
-				f = 8
-				// eedly - final
-			default:
-				break loop
-			}
-		case 9:
-			switch r {
-			case 'n':
-				s = 10
-			default:
-				break loop
-			}
-		case 10:
-			switch r {
-			case 'i':
-				s = 11
-				m = 3
-				f = 11
-				// ing - final
-			default:
-				break loop
-			}
-		case 12:
-			switch r {
-			case 'e':
-				s = 13
-				m = 2
-				f = 13
-				// ed - final
-			default:
-				break loop
-			}
-		case 13:
-			switch r {
-			case 'e':
-				s = 14
-				m = 3
-				f = 14
-				// eed - final
-			default:
-				break loop
-			}
-		default:
-			break loop
-		}
-	}
-
-	//glog.Debugf("rs=%q, l=%d, r1=%d, m=%d, f=%d", string(rs), l, r1, m, f)
-
-switch1b:
-	switch f {
-	case 5, 7, 11, 13:
-		// ingly - final
-		// edly - final
-		// ing - final
-		// ed - final
-
-		// delete if the preceding word part contains a vowel
-		if !hasVowel(rs[:l-m]) {
-			break switch1b
-		}
-		rs = rs[:l-m]
-
-		if len(rs) > 2 {
-			r, rr := rs[len(rs)-1], rs[len(rs)-2]
-
-			// if the word ends at, bl or iz add e (so luxuriat -> luxuriate)
-			if (rr == 'a' && r == 't') || (rr == 'b' && r == 'l') || (rr == 'i' && r == 'z') {
-				rs = append(rs, 'e')
-				break switch1b
-			}
-
-			// if the word ends with a double remove the last letter (so hopp -> hop)
-			if r == rr {
-				switch r {
-				case 'b', 'd', 'f', 'g', 'm', 'n', 'p', 'r', 't':
-					rs = rs[:len(rs)-1]
-					break switch1b
-				}
-			}
-		}
-
-		// if the word is short, add e (so hop -> hope)
-		if isShortWord(rs, r1) {
-			rs = append(rs, 'e')
-			break switch1b
-		}
-
-	case 8:
-		// eedly - final
-		if m >= r1 {
-			rs = rs[:len(rs)-3]
-		}
-
-	case 14:
-		// eed - final
-		if l-r1 >= m {
-			rs = rs[:len(rs)-1]
-		}
-	}
-
-	return rs
-}
-
 // Replace suffix y or Y by i if preceded by a non-vowel which is not the first letter
 // of the word (so cry -> cri, by -> by, say -> say)
-func step1c(rs []rune) []rune {
+func step1c[T letter](rs []T) []T {
 	l := len(rs)
 
 	if l > 2 {
@@ -551,1229 +282,11 @@ func step1c(rs []rune) []rune {
 	return rs
 }
 
-// Search for the longest among the following suffixes, and, if found and in R1,
-// perform the action indicated.
-//
-//   1.  tional -> replace by tion
-//   2.    enci -> replace by ence
-//   3.    anci -> replace by ance
-//   4.    abli -> replace by able
-//   5.   entli -> replace by ent
-//   6.    izer -> replace by ize
-//   7. ization -> replace by ize
-//   8. ational -> replace by ate
-//   9.   ation -> replace by ate
-//  10.    ator -> replace by ate
-//  11.   alism -> replace by al
-//  12.   aliti -> replace by al
-//  13.    alli -> replace by al
-//  14. fulness -> replace by ful
-//  15.   ousli -> replace by ous
-//  16. ousness -> replace by ous
-//  17. iveness -> replace by ive
-//  18.   iviti -> replace by ive
-//  19.  biliti -> replace by ble
-//  20.     bli -> replace by ble
-//  21.     ogi -> replace by og if preceded by l
-//  22.   fulli -> replace by ful
-//  23.  lessli -> replace by less
-//  24.      li -> delete if preceded by a valid li-ending
-func step2(rs []rune, r1 int) []rune {
-	var (
-		l int  = len(rs) // string length
-		m int            // suffix length
-		s int            // state
-		f int            // end state of longgest suffix
-		r rune           // current rune
-	)
-
-loop:
-	for i := 0; i < l; i++ {
-		r = rs[l-i-1]
-
-		switch s {
-
-		case 0:
-			switch r {
-			case 's':
-				s = 1
-			case 'l':
-				s = 14
-			case 'n':
-				s = 21
-			case 'i':
-				s = 28
-			case 'm':
-				s = 46
-			case 'r':
-				s = 61
-			default:
-				break loop
-			}
-		case 1:
-			switch r {
-			case 's':
-				s = 2
-			default:
-				break loop
-			}
-		case 2:
-			switch r {
-			case 'e':
-				s = 3
-			default:
-				break loop
-			}
-		case 3:
-			switch r {
-			case 'n':
-				s = 4
-			default:
-				break loop
-			}
-		case 4:
-			switch r {
-			case 'l':
-				s = 5
-			case 's':
-				s = 8
-			case 'e':
-				s = 11
-			default:
-				break loop
-			}
-		case 5:
-			switch r {
-			case 'u':
-				s = 6
-			default:
-				break loop
-			}
-		case 6:
-			switch r {
-			case 'f':
-				s = 7
-				m = 7
-				f = 7
-				// fulness - final
-			default:
-				break loop
-			}
-		case 8:
-			switch r {
-			case 'u':
-				s = 9
-			default:
-				break loop
-			}
-		case 9:
-			switch r {
-			case 'o':
-				s = 10
-				m = 7
-				f = 10
-				// ousness - final
-			default:
-				break loop
-			}
-		case 11:
-			switch r {
-			case 'v':
-				s = 12
-			default:
-				break loop
-			}
-		case 12:
-			switch r {
-			case 'i':
-				s = 13
-				m = 7
-				f = 13
-				// iveness - final
-			default:
-				break loop
-			}
-		case 14:
-			switch r {
-			case 'a':
-				s = 15
-			default:
-				break loop
-			}
-		case 15:
-			switch r {
-			case 'n':
-				s = 16
-			default:
-				break loop
-			}
-		case 16:
-			switch r {
-			case 'o':
-				s = 17
-			default:
-				break loop
-			}
-		case 17:
-			switch r {
-			case 'i':
-				s = 18
-			default:
-				break loop
-			}
-		case 18:
-			switch r {
-			case 't':
-				s = 19
-				m = 6
-				f = 19
-				// tional - final
-			default:
-				break loop
-			}
-		case 19:
-			switch r {
-			case 'a':
-				s = 20
-				m = 7
-				f = 20
-				// ational - final
-			default:
-				break loop
-			}
-		case 21:
-			switch r {
-			case 'o':
-				s = 22
-			default:
-				break loop
-			}
-		case 22:
-			switch r {
-			case 'i':
-				s = 23
-			default:
-				break loop
-			}
-		case 23:
-			switch r {
-			case 't':
-				s = 24
-			default:
-				break loop
-			}
-		case 24:
-			switch r {
-			case 'a':
-				s = 25
-				m = 5
-				f = 25
-				// ation - final
-			default:
-				break loop
-			}
-		case 25:
-			switch r {
-			case 'z':
-				s = 26
-			default:
-				break loop
-			}
-		case 26:
-			switch r {
-			case 'i':
-				s = 27
-				m = 7
-				f = 27
-				// ization - final
-			default:
-				break loop
-			}
-		case 28:
-			switch r {
-			case 't':
-				s = 29
-			case 'l':
-				s = 34
-				m = 2
-				f = 34
-				// li - final
-			case 'c':
-				s = 55
-			case 'g':
-				s = 69
-			default:
-				break loop
-			}
-		case 29:
-			switch r {
-			case 'i':
-				s = 30
-			default:
-				break loop
-			}
-		case 30:
-			switch r {
-			case 'l':
-				s = 31
-			case 'v':
-				s = 44
-			default:
-				break loop
-			}
-		case 31:
-			switch r {
-			case 'i':
-				s = 32
-			case 'a':
-				s = 54
-				m = 5
-				f = 54
-				// aliti - final
-			default:
-				break loop
-			}
-		case 32:
-			switch r {
-			case 'b':
-				s = 33
-				m = 6
-				f = 33
-				// biliti - final
-			default:
-				break loop
-			}
-		case 34:
-			switch r {
-			case 's':
-				s = 35
-			case 'l':
-				s = 39
-			case 't':
-				s = 51
-			case 'b':
-				s = 59
-				m = 3
-				f = 59
-				// bli - final
-			default:
-				break loop
-			}
-		case 35:
-			switch r {
-			case 's':
-				s = 36
-			case 'u':
-				s = 42
-			default:
-				break loop
-			}
-		case 36:
-			switch r {
-			case 'e':
-				s = 37
-			default:
-				break loop
-			}
-		case 37:
-			switch r {
-			case 'l':
-				s = 38
-				m = 6
-				f = 38
-				// lessli - final
-			default:
-				break loop
-			}
-		case 39:
-			switch r {
-			case 'u':
-				s = 40
-			case 'a':
-				s = 68
-				m = 4
-				f = 68
-				// alli - final
-			default:
-				break loop
-			}
-		case 40:
-			switch r {
-			case 'f':
-				s = 41
-				m = 5
-				f = 41
-				// fulli - final
-			default:
-				break loop
-			}
-		case 42:
-			switch r {
-			case 'o':
-				s = 43
-				m = 5
-				f = 43
-				// ousli - final
-			default:
-				break loop
-			}
-		case 44:
-			switch r {
-			case 'i':
-				s = 45
-				m = 5
-				f = 45
-				// iviti - final
-			default:
-				break loop
-			}
-		case 46:
-			switch r {
-			case 's':
-				s = 47
-			default:
-				break loop
-			}
-		case 47:
-			switch r {
-			case 'i':
-				s = 48
-			default:
-				break loop
-			}
-		case 48:
-			switch r {
-			case 'l':
-				s = 49
-			default:
-				break loop
-			}
-		case 49:
-			switch r {
-			case 'a':
-				s = 50
-				m = 5
-				f = 50
-				// alism - final
-			default:
-				break loop
-			}
-		case 51:
-			switch r {
-			case 'n':
-				s = 52
-			default:
-				break loop
-			}
-		case 52:
-			switch r {
-			case 'e':
-				s = 53
-				m = 5
-				f = 53
-				// entli - final
-			default:
-				break loop
-			}
-		case 55:
-			switch r {
-			case 'n':
-				s = 56
-			default:
-				break loop
-			}
-		case 56:
-			switch r {
-			case 'e':
-				s = 57
-				m = 4
-				f = 57
-				// enci - final
-			case 'a':
-				s = 58
-				m = 4
-				f = 58
-				// anci - final
-			default:
-				break loop
-			}
-		case 59:
-			switch r {
-			case 'a':
-				s = 60
-				m = 4
-				f = 60
-				// abli - final
-			default:
-				break loop
-			}
-		case 61:
-			switch r {
-			case 'e':
-				s = 62
-			case 'o':
-				s = 65
-			default:
-				break loop
-			}
-		case 62:
-			switch r {
-			case 'z':
-				s = 63
-			default:
-				break loop
-			}
-		case 63:
-			switch r {
-			case 'i':
-				s = 64
-				m = 4
-				f = 64
-				// izer - final
-			default:
-				break loop
-			}
-		case 65:
-			switch r {
-			case 't':
-				s = 66
-			default:
-				break loop
-			}
-		case 66:
-			switch r {
-			case 'a':
-				s = 67
-				m = 4
-				f = 67
-				// ator - final
-			default:
-				break loop
-			}
-		case 69:
-			switch r {
-			case 'o':
-				s = 70
-				m = 3
-				f = 70
-				// ogi - final
-			default:
-				break loop
-			}
-		default:
-			break loop
-		}
-	}
-
-	if l-r1 < m {
-		return rs
-	}
-
-	switch f {
-	case 7, 10, 13:
-		// fulness - final
-		// ousness - final
-		// iveness - final
-		rs = rs[:l-4]
-
-	case 19, 38, 41, 43, 53, 68:
-		// tional - final
-		// lessli - final
-		// fulli - final
-		// ousli - final
-		// entli - final
-		// alli - final
-		rs = rs[:l-2]
-
-	case 20, 27:
-		// ational - final
-		// ization - final
-		rs[l-5] = 'e'
-		rs = rs[:l-4]
-
-	case 25, 45:
-		// ation - final
-		// iviti - final
-		rs[l-3] = 'e'
-		rs = rs[:l-2]
-
-	case 33:
-		// biliti - final
-		rs[l-5] = 'l'
-		rs[l-4] = 'e'
-		rs = rs[:l-3]
-
-	case 34:
-		// li - final
-		if l > 2 {
-			switch rs[l-3] {
-			case 'c', 'd', 'e', 'g', 'h', 'k', 'm', 'n', 'r', 't':
-				rs = rs[:l-2]
-			}
-		}
-
-	case 50, 54:
-		// alism - final
-		// aliti - final
-		rs = rs[:l-3]
-
-	case 57, 58, 59, 60:
-		// enci - final
-		// anci - final
-		// abli - final
-		// bli - final
-		rs[l-1] = 'e'
-
-	case 64:
-		// izer - final
-		rs = rs[:l-1]
-
-	case 67:
-		// ator - final
-		rs[l-2] = 'e'
-		rs = rs[:l-1]
-
-	case 70:
-		// ogi - final
-		if l > 3 && rs[l-4] == 'l' {
-			rs = rs[:l-1]
-		}
-
-	}
-
-	return rs
-}
-
-// Search for the longest among the following suffixes, and, if found and in R1,
-// perform the action indicated.
-//
-// 1.  tional -> replace by tion
-// 2. ational -> replace by ate
-// 3.   alize -> replace by al
-// 4.   icate -> replace by ic
-// 5.   iciti -> replace by ic
-// 6.    ical -> replace by ic
-// 7.     ful -> delete
-// 8.    ness -> delete
-// 9.   ative -> delete if in R2
-func step3(rs []rune, r1, r2 int) []rune {
-	var (
-		l int  = len(rs) // string length
-		m int            // suffix length
-		s int            // state
-		f int            // end state of longgest suffix
-		r rune           // current rune
-	)
-
-loop:
-	for i := 0; i < l; i++ {
-		r = rs[l-i-1]
-
-		switch s {
-
-		case 0:
-			switch r {
-			case 'l':
-				s = 1
-			case 'e':
-				s = 8
-			case 'i':
-				s = 17
-			case 's':
-				s = 26
-			default:
-				break loop
-			}
-		case 1:
-			switch r {
-			case 'a':
-				s = 2
-			case 'u':
-				s = 24
-			default:
-				break loop
-			}
-		case 2:
-			switch r {
-			case 'n':
-				s = 3
-			case 'c':
-				s = 22
-			default:
-				break loop
-			}
-		case 3:
-			switch r {
-			case 'o':
-				s = 4
-			default:
-				break loop
-			}
-		case 4:
-			switch r {
-			case 'i':
-				s = 5
-			default:
-				break loop
-			}
-		case 5:
-			switch r {
-			case 't':
-				s = 6
-				m = 6
-				f = 6
-				// tional - final
-			default:
-				break loop
-			}
-		case 6:
-			switch r {
-			case 'a':
-				s = 7
-				m = 7
-				f = 7
-				// ational - final
-			default:
-				break loop
-			}
-		case 8:
-			switch r {
-			case 'z':
-				s = 9
-			case 't':
-				s = 13
-			case 'v':
-				s = 30
-			default:
-				break loop
-			}
-		case 9:
-			switch r {
-			case 'i':
-				s = 10
-			default:
-				break loop
-			}
-		case 10:
-			switch r {
-			case 'l':
-				s = 11
-			default:
-				break loop
-			}
-		case 11:
-			switch r {
-			case 'a':
-				s = 12
-				m = 5
-				f = 12
-				// alize - final
-			default:
-				break loop
-			}
-		case 13:
-			switch r {
-			case 'a':
-				s = 14
-			default:
-				break loop
-			}
-		case 14:
-			switch r {
-			case 'c':
-				s = 15
-			default:
-				break loop
-			}
-		case 15:
-			switch r {
-			case 'i':
-				s = 16
-				m = 5
-				f = 16
-				// icate - final
-			default:
-				break loop
-			}
-		case 17:
-			switch r {
-			case 't':
-				s = 18
-			default:
-				break loop
-			}
-		case 18:
-			switch r {
-			case 'i':
-				s = 19
-			default:
-				break loop
-			}
-		case 19:
-			switch r {
-			case 'c':
-				s = 20
-			default:
-				break loop
-			}
-		case 20:
-			switch r {
-			case 'i':
-				s = 21
-				m = 5
-				f = 21
-				// iciti - final
-			default:
-				break loop
-			}
-		case 22:
-			switch r {
-			case 'i':
-				s = 23
-				m = 4
-				f = 23
-				// ical - final
-			default:
-				break loop
-			}
-		case 24:
-			switch r {
-			case 'f':
-				s = 25
-				m = 3
-				f = 25
-				// ful - final
-			default:
-				break loop
-			}
-		case 26:
-			switch r {
-			case 's':
-				s = 27
-			default:
-				break loop
-			}
-		case 27:
-			switch r {
-			case 'e':
-				s = 28
-			default:
-				break loop
-			}
-		case 28:
-			switch r {
-			case 'n':
-				s = 29
-				m = 4
-				f = 29
-				// ness - final
-			default:
-				break loop
-			}
-		case 30:
-			switch r {
-			case 'i':
-				s = 31
-			default:
-				break loop
-			}
-		case 31:
-			switch r {
-			case 't':
-				s = 32
-			default:
-				break loop
-			}
-		case 32:
-			switch r {
-			case 'a':
-				s = 33
-				m = 5
-				f = 33
-				// ative - final
-			default:
-				break loop
-			}
-		default:
-			break loop
-		}
-	}
-
-	//glog.Debugf("rs=%q, l=%d, r1=%d, r2=%d, m=%d, s=%d, f=%d", string(rs), l, r1, r2, m, s, f)
-
-	// if not found and in R1, do nothing
-	if l-r1 < m {
-		return rs
-	}
-
-	switch f {
-	case 6, 23:
-		// tional - final
-		// ical - final
-		rs = rs[:l-2]
-
-	case 7:
-		// ational - final
-		rs[l-5] = 'e'
-		rs = rs[:l-4]
-
-	case 12, 16, 21:
-		// alize - final
-		// icate - final
-		// iciti - final
-		rs = rs[:l-3]
-
-	case 25, 29:
-		// ful - final
-		// ness - final
-		rs = rs[:l-m]
-
-	case 33:
-		// ative - final
-		// delete if in R2
-		if l-r2 >= m {
-			rs = rs[:l-m]
-		}
-	}
-
-	return rs
-}
-
-// Search for the longest among the following suffixes, and, if found and in R2,
-// perform the action indicated.
-//
-//   1.  able -> delete
-//   2.    al -> delete
-//   3.  ance -> delete
-//   4.   ant -> delete
-//   5.   ate -> delete
-//   6. ement -> delete
-//   7.  ence -> delete
-//   8.   ent -> delete
-//   9.    er -> delete
-//  10.  ible -> delete
-//  11.    ic -> delete
-//  12.   ism -> delete
-//  13.   iti -> delete
-//  14.   ive -> delete
-//  15.   ize -> delete
-//  16.  ment -> delete
-//  17.   ous -> delete
-//  18.   ion -> delete if preceded by s or t
-func step4(rs []rune, r2 int) []rune {
-	var (
-		l int  = len(rs) // string length
-		m int            // suffix length
-		s int            // state
-		f int            // end state of longgest suffix
-		r rune           // current rune
-	)
-
-loop:
-	for i := 0; i < l; i++ {
-		r = rs[l-i-1]
-
-		switch s {
-
-		case 0:
-			switch r {
-			case 'e':
-				s = 1
-			case 'l':
-				s = 5
-			case 't':
-				s = 10
-			case 'r':
-				s = 19
-			case 'c':
-				s = 22
-			case 'm':
-				s = 24
-			case 'i':
-				s = 27
-			case 's':
-				s = 34
-			case 'n':
-				s = 37
-			default:
-				break loop
-			}
-		case 1:
-			switch r {
-			case 'l':
-				s = 2
-			case 'c':
-				s = 7
-			case 't':
-				s = 13
-			case 'v':
-				s = 30
-			case 'z':
-				s = 32
-			default:
-				break loop
-			}
-		case 2:
-			switch r {
-			case 'b':
-				s = 3
-			default:
-				break loop
-			}
-		case 3:
-			switch r {
-			case 'a':
-				s = 4
-				m = 4
-				f = 4
-				// able - final
-			case 'i':
-				s = 21
-				m = 4
-				f = 21
-				// ible - final
-			default:
-				break loop
-			}
-		case 5:
-			switch r {
-			case 'a':
-				s = 6
-				m = 2
-				f = 6
-				// al - final
-			default:
-				break loop
-			}
-		case 7:
-			switch r {
-			case 'n':
-				s = 8
-			default:
-				break loop
-			}
-		case 8:
-			switch r {
-			case 'a':
-				s = 9
-				m = 4
-				f = 9
-				// ance - final
-			case 'e':
-				s = 18
-				m = 4
-				f = 18
-				// ence - final
-			default:
-				break loop
-			}
-		case 10:
-			switch r {
-			case 'n':
-				s = 11
-			default:
-				break loop
-			}
-		case 11:
-			switch r {
-			case 'a':
-				s = 12
-				m = 3
-				f = 12
-				// ant - final
-			case 'e':
-				s = 15
-				m = 3
-				f = 15
-				// ent - final
-			default:
-				break loop
-			}
-		case 13:
-			switch r {
-			case 'a':
-				s = 14
-				m = 3
-				f = 14
-				// ate - final
-			default:
-				break loop
-			}
-		case 15:
-			switch r {
-			case 'm':
-				s = 16
-				m = 4
-				f = 16
-				// ment - final
-			default:
-				break loop
-			}
-		case 16:
-			switch r {
-			case 'e':
-				s = 17
-				m = 5
-				f = 17
-				// ement - final
-			default:
-				break loop
-			}
-		case 19:
-			switch r {
-			case 'e':
-				s = 20
-				m = 2
-				f = 20
-				// er - final
-			default:
-				break loop
-			}
-		case 22:
-			switch r {
-			case 'i':
-				s = 23
-				m = 2
-				f = 23
-				// ic - final
-			default:
-				break loop
-			}
-		case 24:
-			switch r {
-			case 's':
-				s = 25
-			default:
-				break loop
-			}
-		case 25:
-			switch r {
-			case 'i':
-				s = 26
-				m = 3
-				f = 26
-				// ism - final
-			default:
-				break loop
-			}
-		case 27:
-			switch r {
-			case 't':
-				s = 28
-			default:
-				break loop
-			}
-		case 28:
-			switch r {
-			case 'i':
-				s = 29
-				m = 3
-				f = 29
-				// iti - final
-			default:
-				break loop
-			}
-		case 30:
-			switch r {
-			case 'i':
-				s = 31
-				m = 3
-				f = 31
-				// ive - final
-			default:
-				break loop
-			}
-		case 32:
-			switch r {
-			case 'i':
-				s = 33
-				m = 3
-				f = 33
-				// ize - final
-			default:
-				break loop
-			}
-		case 34:
-			switch r {
-			case 'u':
-				s = 35
-			default:
-				break loop
-			}
-		case 35:
-			switch r {
-			case 'o':
-				s = 36
-				m = 3
-				f = 36
-				// ous - final
-			default:
-				break loop
-			}
-		case 37:
-			switch r {
-			case 'o':
-				s = 38
-			default:
-				break loop
-			}
-		case 38:
-			switch r {
-			case 'i':
-				s = 39
-				m = 3
-				f = 39
-				// ion - final
-			default:
-				break loop
-			}
-		default:
-			break loop
-		}
-	}
-
-	//glog.Debugf("rs=%q, l=%d, r2=%d, m=%d, f=%d", string(rs), l, r2, m, f)
-	if l-r2 < m {
-		return rs
-	}
-
-	switch f {
-	case 4, 6, 9, 12, 14, 15, 16, 17, 18, 20, 21, 23, 26, 29, 31, 33, 36:
-		// able - final
-		// al - final
-		// ance - final
-		// ant - final
-		// ate - final
-		// ent - final
-		// ment - final
-		// ement - final
-		// ence - final
-		// er - final
-		// ible - final
-		// ic - final
-		// ism - final
-		// iti - final
-		// ive - final
-		// ize - final
-		// ous - final
-		rs = rs[:l-m]
-
-	case 39:
-		// ion - final
-		if l >= 4 && (rs[l-4] == 's' || rs[l-4] == 't') {
-			rs = rs[:l-3]
-		}
-
-	}
-
-	return rs
-}
-
 // Search for the the following suffixes, and, if found, perform the action indicated.
 //
 // e -> delete if in R2, or in R1 and not preceded by a short syllable
 // l -> delete if in R2 and preceded by l
-func step5(rs []rune, r1, r2 int) []rune {
+func step5[T letter](rs []T, r1, r2 int) []T {
 	l := len(rs)
 	if l < 1 {
 		return rs
@@ -1807,7 +320,7 @@ func step5(rs []rune, r1, r2 int) []rune {
 }
 
 // Finally, turn any remaining Y letters in the word back into lower case.
-func postlude(rs []rune) []rune {
+func postlude[T letter](rs []T) []T {
 	for i, r := range rs {
 		if r == 'Y' {
 			rs[i] = 'y'
@@ -1817,33 +330,33 @@ func postlude(rs []rune) []rune {
 	return rs
 }
 
-// word exceptions list 1. Can't do a map since we have a []rune, and []rune cannot
+// word exceptions list 1. Can't do a map since we have a []T, and []T cannot
 // be a key to the map..argh..
 //
 // Returns true if word is an exception, false if not. The replacement word is
 // returned if true. Otherwise the same word is returned if false.
 //
-//  andes -> andes
-//  atlas -> atlas
-//  bias -> bias
-//  cosmos -> cosmos
-//  dying -> die
-//  early -> earli
-//  gently -> gentl
-//  howe -> howe
-//  idly -> idl
-//  lying -> lie
-//  news -> news
-//  only -> onli
-//  singly -> singl
-//  skies -> sky
-//  skis -> ski
-//  sky -> sky
-//  tying -> tie
-//  ugly -> ugli
-func exception1(rs []rune) ([]rune, bool) {
+//	andes -> andes
+//	atlas -> atlas
+//	bias -> bias
+//	cosmos -> cosmos
+//	dying -> die
+//	early -> earli
+//	gently -> gentl
+//	howe -> howe
+//	idly -> idl
+//	lying -> lie
+//	news -> news
+//	only -> onli
+//	singly -> singl
+//	skies -> sky
+//	skis -> ski
+//	sky -> sky
+//	tying -> tie
+//	ugly -> ugli
+func exception1[T letter](rs []T) ([]T, bool) {
 	l := len(rs)
-	if l > 6 {
+	if l == 0 || l > 6 {
 		return rs, false
 	}
 
@@ -1972,15 +485,15 @@ func exception1(rs []rune) ([]rune, bool) {
 
 // Following step 1a, leave the following invariant,
 //
-//  inning
-//  outing
-// canning
-// herring
-// earring
-// proceed
-//  exceed
-// succeed
-func exception2(rs []rune) bool {
+//	inning
+//	outing
+//	canning
+//	herring
+//	earring
+//	proceed
+//	exceed
+//	succeed
+func exception2[T letter](rs []T) bool {
 	l := len(rs)
 	if l != 6 && l != 7 {
 		return false
@@ -2052,7 +565,7 @@ func exception2(rs []rune) bool {
 	return true
 }
 
-func isVowel(r rune) bool {
+func isVowel[T letter](r T) bool {
 	switch r {
 	case 'a', 'e', 'i', 'o', 'u', 'y':
 		return true
@@ -2061,7 +574,7 @@ func isVowel(r rune) bool {
 	return false
 }
 
-func hasVowel(rs []rune) bool {
+func hasVowel[T letter](rs []T) bool {
 	for _, r := range rs {
 		if isVowel(r) {
 			return true
@@ -2071,12 +584,62 @@ func hasVowel(rs []rune) bool {
 	return false
 }
 
+// hasVowelBeforeLast returns true if rs contains a vowel before its last letter,
+// i.e., a vowel not immediately before the s removed by step1a.
+func hasVowelBeforeLast[T letter](rs []T) bool {
+	return len(rs) > 1 && hasVowel(rs[:len(rs)-1])
+}
+
+// ie finishes the ied and ies suffixes of step1a once they're removed: it adds
+// i if the word has more than one letter left, otherwise ie (so ties -> tie,
+// cries -> cri).
+func ie[T letter](rs []T) []T {
+	if len(rs) > 1 {
+		return append(rs, 'i')
+	}
+
+	return append(rs, 'i', 'e')
+}
+
+// fixEnding finishes the ingly, edly, ing and ed suffixes of step1b once they're
+// removed:
+//
+//	if the word ends at, bl or iz add e (so luxuriat -> luxuriate), or
+//	if the word ends with a double remove the last letter (so hopp -> hop), or
+//	if the word is short, add e (so hop -> hope)
+func fixEnding[T letter](rs []T, r1 int) []T {
+	if len(rs) > 2 {
+		r, rr := rs[len(rs)-1], rs[len(rs)-2]
+
+		// if the word ends at, bl or iz add e (so luxuriat -> luxuriate)
+		if (rr == 'a' && r == 't') || (rr == 'b' && r == 'l') || (rr == 'i' && r == 'z') {
+			return append(rs, 'e')
+		}
+
+		// if the word ends with a double remove the last letter (so hopp -> hop)
+		if r == rr {
+			switch r {
+			case 'b', 'd', 'f', 'g', 'm', 'n', 'p', 'r', 't':
+				return rs[:len(rs)-1]
+			}
+		}
+	}
+
+	// if the word is short, add e (so hop -> hope)
+	if isShortWord(rs, r1) {
+		return append(rs, 'e')
+	}
+
+	return rs
+}
+
 // A word is called short if it ends in a short syllable, and if R1 is null.
 //
 // Define a short syllable in a word as either
-//  (a) a vowel followed by a non-vowel other than w, x or Y and preceded by a non-vowel, or
-//  (b) a vowel at the beginning of the word followed by a non-vowel.
-func isShortWord(rs []rune, r1 int) bool {
+//
+//	(a) a vowel followed by a non-vowel other than w, x or Y and preceded by a non-vowel, or
+//	(b) a vowel at the beginning of the word followed by a non-vowel.
+func isShortWord[T letter](rs []T, r1 int) bool {
 	if r1 < len(rs) {
 		return false
 	}
@@ -2084,7 +647,7 @@ func isShortWord(rs []rune, r1 int) bool {
 	return isShortSyllable(rs)
 }
 
-func isShortSyllable(rs []rune) bool {
+func isShortSyllable[T letter](rs []T) bool {
 	l := len(rs)
 
 	switch l {
@@ -2109,3 +672,14 @@ func isShortSyllable(rs []rune) bool {
 
 	return false
 }
+
+// lower returns the lower case form of r. The characters that the Snowball english
+// stemmer treats as apostrophes, U+2018, U+2019 and U+201B, are turned into ',
+// so that curly quotes are handled by preclude and step0 just like ' is.
+func lower(r rune) rune {
+	if isApostrophe(r) {
+		return '\''
+	}
+
+	return unicode.ToLower(r)
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/surge/glog"
+	"github.com/surgebase/porter2/internal/letters"
 )
 
 var (
@@ -493,6 +494,252 @@ func TestEnglishVocOutput(t *testing.T) {
 	}
 }
 
+func TestEnglishVocOutputBytes(t *testing.T) {
+	bs, stems := loadVocBytes("voc.txt", "output.txt")
+
+	var dst []byte
+	for i, word := range bs {
+		dst = AppendStem(dst[:0], word)
+		assert.Equal(t, stems[i], string(dst), string(word))
+
+		dst = English.AppendStem(dst[:0], word)
+		assert.Equal(t, stems[i], string(dst), string(word))
+
+		assert.Equal(t, stems[i], string(StemBytes(word)), string(word))
+	}
+}
+
+func TestEnglishASCIIMatchesRunes(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	for i, word := range words {
+		if len(word) <= 2 {
+			continue
+		}
+
+		assert.True(t, letters.IsASCII(word), word)
+
+		bs := string(stem(letters.ToLowerASCII([]byte(word)), nil))
+		rs := string(stem([]rune(strings.ToLower(word)), nil))
+		assert.Equal(t, rs, bs, word)
+		assert.Equal(t, stems[i], bs, word)
+	}
+}
+
+func TestEnglishNonASCII(t *testing.T) {
+	// Words with non-ASCII letters take the rune path, and must still be
+	// stemmed around those letters.
+	for word, expect := range map[string]string{
+		"Cafés":     "café",
+		"naïvely":   "naïv",
+		"Ångström":  "ångström",
+		"résumés":   "résumé",
+		"façades":   "façad",
+		"jalapeños": "jalapeño",
+	} {
+		assert.Equal(t, expect, Stem(word), word)
+		assert.Equal(t, expect, string(AppendStem(nil, []byte(word))), word)
+	}
+}
+
+func TestEnglishApostrophes(t *testing.T) {
+	for word, expect := range map[string]string{
+		"dog\u2019s":          "dog",
+		"dogs\u2019":          "dog",
+		"dog\u2019s\u2019":    "dog",
+		"\u2018tis":           "tis",
+		"\u201Bgeneral":       "general",
+		"O\u2019Neill\u2019s": "o'neil",
+		"caf\u00e9\u2019s":    "caf\u00e9",
+		"\u2019\u2019\u2019":  "'",
+		"\u2019\u2019s":       "",
+
+		// two letters or less, counted in runes, are left as they are
+		"\u2019":       "\u2019",
+		"\u2019\u2019": "\u2019\u2019",
+		"\u2019s":      "\u2019s",
+	} {
+		assert.Equal(t, expect, Stem(word), word)
+		assert.Equal(t, expect, string(AppendStem(nil, []byte(word))), word)
+		assert.Equal(t, expect, StemTrace(word).Stem, word)
+	}
+
+	// every word in the vocabulary with a ' stems the same with its curly variants
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	for i, word := range words {
+		if len(word) <= 2 || !strings.Contains(word, "'") {
+			continue
+		}
+
+		for _, q := range []string{"\u2018", "\u2019", "\u201B"} {
+			curly := strings.Replace(word, "'", q, -1)
+			assert.Equal(t, stems[i], Stem(curly), curly)
+			assert.Equal(t, stems[i], string(AppendStem(nil, []byte(curly))), curly)
+		}
+	}
+}
+
+func TestEnglishAppendStemAllocs(t *testing.T) {
+	bs, _ := loadVocBytes("voc.txt", "output.txt")
+
+	dst := make([]byte, 0, 256)
+	allocs := testing.AllocsPerRun(10, func() {
+		for _, word := range bs {
+			dst = AppendStem(dst[:0], word)
+		}
+	})
+	assert.Equal(t, 0.0, allocs)
+
+	// StemBytes overwrites the word, so each run stems a fresh copy
+	buf := make([]byte, 0, 256)
+	allocs = testing.AllocsPerRun(10, func() {
+		for _, word := range bs {
+			StemBytes(append(buf[:0], word...))
+		}
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkEnglishStem(b *testing.B) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range words {
+			Stem(word)
+		}
+	}
+}
+
+func BenchmarkEnglishAppendStem(b *testing.B) {
+	bs, _ := loadVocBytes("voc.txt", "output.txt")
+
+	dst := make([]byte, 0, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range bs {
+			dst = AppendStem(dst[:0], word)
+		}
+	}
+}
+
+func BenchmarkEnglishStemBytes(b *testing.B) {
+	bs, _ := loadVocBytes("voc.txt", "output.txt")
+
+	buf := make([]byte, 0, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range bs {
+			StemBytes(append(buf[:0], word...))
+		}
+	}
+}
+
+func TestEnglishTableSteps(t *testing.T) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+
+	// both sides may change the word in place, so each gets its own copy
+	same := func(word string, step, table func([]byte) []byte) {
+		assert.Equal(t, string(step([]byte(word))), string(table([]byte(word))), word)
+	}
+
+	for _, word := range words {
+		r1, r2 := markR1R2([]byte(word))
+
+		same(word, step0[byte], step0Table[byte])
+		same(word, step1a[byte], step1aTable[byte])
+		same(word,
+			func(bs []byte) []byte { return step1b(bs, r1) },
+			func(bs []byte) []byte { return step1bTable(bs, r1) })
+		same(word,
+			func(bs []byte) []byte { return step2(bs, r1) },
+			func(bs []byte) []byte { return step2Table(bs, r1) })
+		same(word,
+			func(bs []byte) []byte { return step3(bs, r1, r2) },
+			func(bs []byte) []byte { return step3Table(bs, r1, r2) })
+		same(word,
+			func(bs []byte) []byte { return step4(bs, r2) },
+			func(bs []byte) []byte { return step4Table(bs, r2) })
+	}
+
+	// letters that don't fit in a byte stop the table-driven state machines
+	m, _ := suffix2Table([]rune("rōtational"))
+	assert.Equal(t, 7, m)
+	m, _ = suffix2Table([]rune("rotatiōnal"))
+	assert.Equal(t, 0, m)
+}
+
+// benchmarkSuffixes runs the suffix state machine fsm over every word in the vocabulary.
+func benchmarkSuffixes(b *testing.B, fsm func([]byte) (int, int)) {
+	bs, _ := loadVocBytes("voc.txt", "output.txt")
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range bs {
+			fsm(word)
+		}
+	}
+}
+
+func BenchmarkEnglishSuffix2Switch(b *testing.B) {
+	benchmarkSuffixes(b, suffix2[byte])
+}
+
+func BenchmarkEnglishSuffix2Table(b *testing.B) {
+	benchmarkSuffixes(b, suffix2Table[byte])
+}
+
+func BenchmarkEnglishSuffix4Switch(b *testing.B) {
+	benchmarkSuffixes(b, suffix4[byte])
+}
+
+func BenchmarkEnglishSuffix4Table(b *testing.B) {
+	benchmarkSuffixes(b, suffix4Table[byte])
+}
+
+// loadVoc reads the vocabulary and the expected output into memory, one word per line.
+func loadVoc(vocname, outname string) ([]string, []string) {
+	inscan, infile := openFile(vocname)
+	outscan, outfile := openFile(outname)
+	defer infile.Close()
+	defer outfile.Close()
+
+	var words, stems []string
+
+	for inscan.Scan() {
+		if !outscan.Scan() {
+			break
+		}
+
+		words = append(words, inscan.Text())
+		stems = append(stems, outscan.Text())
+	}
+
+	return words, stems
+}
+
+// loadVocBytes is loadVoc with the words as byte slices, for AppendStem and StemBytes.
+func loadVocBytes(vocname, outname string) ([][]byte, []string) {
+	words, stems := loadVoc(vocname, outname)
+
+	bs := make([][]byte, len(words))
+	for i, word := range words {
+		bs[i] = []byte(word)
+	}
+
+	return bs, stems
+}
+
 func openFile(fname string) (*bufio.Scanner, *os.File) {
 	var s *bufio.Scanner
 
@@ -0,0 +1,72 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"strings"
+
+	"github.com/surgebase/porter2/internal/letters"
+)
+
+// WithProtected protects words from stemming, like the keyword markers found in
+// search engines. Protected words, such as brand names, acronyms or ticker
+// symbols, are returned exactly as they are given to the stemmer. Words are
+// matched without regard to case or the kind of apostrophe they use. It can be
+// given more than once, in which case the words are merged.
+func WithProtected(words ...string) Option {
+	return func(e *english) {
+		if e.protected == nil {
+			e.protected = make(map[string]struct{}, len(words))
+		}
+
+		for _, word := range words {
+			word = strings.Map(lower, word)
+			e.protected[word] = struct{}{}
+			e.protectedLens |= lenBit(len(word))
+		}
+	}
+}
+
+// isProtected returns true if word is one of the protected words in e, which is
+// nil for the plain algorithm. For ASCII words, it does not allocate, and most
+// words are ruled out by their length before the map is consulted.
+func isProtected[S string | []byte](e *english, word S) bool {
+	if e == nil || e.protected == nil {
+		return false
+	}
+
+	if letters.IsASCII(word) {
+		if e.protectedLens&lenBit(len(word)) == 0 {
+			return false
+		}
+
+		var buf [letters.StackRunes]byte
+		_, ok := e.protected[string(letters.ToLowerASCII(append(buf[:0], word...)))]
+		return ok
+	}
+
+	_, ok := e.protected[strings.Map(lower, string(word))]
+	return ok
+}
+
+// lenBit returns the bit for words of n bytes in english.protectedLens. Words
+// of 63 bytes or more share the last bit.
+func lenBit(n int) uint64 {
+	if n > 63 {
+		n = 63
+	}
+
+	return 1 << uint(n)
+}
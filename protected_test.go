@@ -0,0 +1,123 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	protectedWords []string = []string{"Gophers", "AAPL", "Ångströms", "NEWS", "Kubernetes", "McDonald\u2019s"}
+
+	protectedStems map[string]string = map[string]string{
+		"Gophers":         "Gophers",
+		"gophers":         "gophers",
+		"GOPHERS":         "GOPHERS",
+		"AAPL":            "AAPL",
+		"aapl":            "aapl",
+		"Ångströms":       "Ångströms",
+		"ÅNGSTRÖMS":       "ÅNGSTRÖMS",
+		"news":            "news",
+		"Kubernetes":      "Kubernetes",
+		"McDonald's":      "McDonald's",
+		"MCDONALD\u2018S": "MCDONALD\u2018S",
+
+		// everything else is still stemmed
+		"gopher's":   "gopher",
+		"ångström's": "ångström",
+		"generously": "generous",
+		"Running":    "run",
+	}
+)
+
+func TestProtected(t *testing.T) {
+	s := NewEnglish(WithProtected(protectedWords...))
+
+	for word, expect := range protectedStems {
+		assert.Equal(t, expect, s.Stem(word), word)
+		assert.Equal(t, expect, string(s.AppendStem(nil, []byte(word))), word)
+		assert.Equal(t, expect, string(s.AppendStem([]byte("x"), []byte(word))[1:]), word)
+	}
+
+	// StemBytes overwrites the word, so it must still be there when it's protected
+	bs := []byte("Gophers")
+	assert.Equal(t, "Gophers", string(NewEnglish(WithProtected("gophers")).AppendStem(bs[:0], bs)))
+
+	assert.Equal(t, "kubernet", Stem("Kubernetes"))
+}
+
+func TestProtectedWithExceptions(t *testing.T) {
+	s := NewEnglish(
+		WithProtected("gophers"),
+		WithExceptions(&Exceptions{Stems: map[string]string{"gophers": "go", "cats": "kitten"}}),
+	)
+
+	assert.Equal(t, "Gophers", s.Stem("Gophers"))
+	assert.Equal(t, "kitten", s.Stem("cats"))
+}
+
+func TestProtectedAllocs(t *testing.T) {
+	bs, stems := loadVocBytes("voc.txt", "output.txt")
+
+	// protect every 10th word of the vocabulary
+	var protected []string
+	for i := 0; i < len(bs); i += 10 {
+		protected = append(protected, string(bs[i]))
+	}
+
+	s := NewEnglish(WithProtected(protected...))
+
+	for i, word := range bs {
+		expect := stems[i]
+		if i%10 == 0 {
+			expect = string(word)
+		}
+		assert.Equal(t, expect, s.Stem(string(word)), string(word))
+	}
+
+	dst := make([]byte, 0, 256)
+	allocs := testing.AllocsPerRun(10, func() {
+		for _, word := range bs {
+			dst = s.AppendStem(dst[:0], word)
+		}
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkProtectedAppendStem(b *testing.B) {
+	bs, _ := loadVocBytes("voc.txt", "output.txt")
+
+	// protect 10,000 words that aren't in the vocabulary, the worst case since
+	// none of them can return early
+	var protected []string
+	for i := 0; i < 10000; i++ {
+		protected = append(protected, fmt.Sprintf("zz%d", i))
+	}
+
+	s := NewEnglish(WithProtected(protected...))
+	dst := make([]byte, 0, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, word := range bs {
+			dst = s.AppendStem(dst[:0], word)
+		}
+	}
+}
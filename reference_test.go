@@ -0,0 +1,96 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/surgebase/porter2/internal/reference"
+)
+
+// diverge compares the trace of word with the reference implementation, stage
+// by stage, and describes the first stage where they differ, or returns "" if
+// they agree all the way to the stem.
+func diverge(word string) string {
+	t, r := StemTrace(word), reference.Run(word)
+
+	switch {
+	case t.Exception1 != r.Exception1:
+		return fmt.Sprintf("exception1: %t, reference %t", t.Exception1, r.Exception1)
+
+	case t.Preclude != r.Preclude:
+		return fmt.Sprintf("preclude: %q, reference %q", t.Preclude, r.Preclude)
+
+	case t.R1 != r.R1 || t.R2 != r.R2:
+		return fmt.Sprintf("regions: R1=%d R2=%d, reference R1=%d R2=%d", t.R1, t.R2, r.R1, r.R2)
+	}
+
+	for i, ts := range t.Steps {
+		if i >= len(r.Steps) {
+			return fmt.Sprintf("%s: %q, reference stopped", ts.Name, ts.Output)
+		}
+
+		if rs := r.Steps[i]; ts.Name != rs.Name || ts.Output != rs.Word {
+			return fmt.Sprintf("%s: %s -> %q, reference %s -> %q", ts.Name, ts.Input, ts.Output, rs.Name, rs.Word)
+		}
+	}
+
+	switch {
+	case len(r.Steps) > len(t.Steps):
+		return fmt.Sprintf("%s: stopped, reference %q", r.Steps[len(t.Steps)].Name, r.Steps[len(t.Steps)].Word)
+
+	case t.Exception2 != r.Exception2:
+		return fmt.Sprintf("exception2: %t, reference %t", t.Exception2, r.Exception2)
+
+	case t.Stem != r.Stem:
+		return fmt.Sprintf("postlude: %q, reference %q", t.Stem, r.Stem)
+
+	case Stem(word) != t.Stem:
+		return fmt.Sprintf("Stem: %q, trace %q", Stem(word), t.Stem)
+	}
+
+	return ""
+}
+
+func TestReferenceVoc(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	for i, word := range words {
+		if d := diverge(word); d != "" {
+			t.Errorf("%s: %s", word, d)
+		}
+
+		if stem := reference.Stem(word); stem != stems[i] {
+			t.Errorf("reference.Stem(%q) = %q, expected %q", word, stem, stems[i])
+		}
+	}
+
+	for _, word := range fuzzEdges {
+		if d := diverge(word); d != "" {
+			t.Errorf("%q: %s", word, d)
+		}
+	}
+}
+
+func FuzzReference(f *testing.F) {
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, word string) {
+		if d := diverge(word); d != "" {
+			t.Errorf("%q: %s", word, d)
+		}
+	})
+}
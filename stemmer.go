@@ -0,0 +1,130 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Stemmer is a stemming algorithm. Implementations must be safe for concurrent
+// use by multiple goroutines.
+type Stemmer interface {
+	// Stem returns the stemmed version of s.
+	Stem(s string) string
+
+	// AppendStem appends the stemmed version of word to dst and returns the
+	// extended buffer.
+	AppendStem(dst, word []byte) []byte
+}
+
+// English is the Porter2 english stemmer, i.e., the algorithm behind Stem and
+// AppendStem. It is registered as both "english" and "porter2".
+var English Stemmer = &english{}
+
+// english is the Porter2 english stemmer, along with the options it was created with.
+type english struct {
+	stems      map[string]string   // user supplied word to stem overrides, in lower case
+	invariants map[string]struct{} // user supplied invariants following step1a, after preclude
+
+	protected     map[string]struct{} // words that are not stemmed, in lower case
+	protectedLens uint64              // bit n is set if a protected word is n bytes long
+
+	fold bool // remove accents before stemming
+}
+
+// Option configures a stemmer created by NewEnglish.
+type Option func(*english)
+
+// NewEnglish returns a Porter2 english stemmer configured by opts. With no
+// options, it is the same as English.
+func NewEnglish(opts ...Option) Stemmer {
+	e := &english{}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	// the options may come in any order, so the exceptions are folded last
+	if e.fold {
+		e.foldExceptions()
+	}
+
+	return e
+}
+
+func (e *english) Stem(s string) string {
+	return stemString(s, e)
+}
+
+func (e *english) AppendStem(dst, word []byte) []byte {
+	return appendStem(dst, word, e)
+}
+
+var (
+	stemmersMu sync.RWMutex
+	stemmers   = make(map[string]Stemmer)
+)
+
+func init() {
+	Register("english", English)
+	Register("porter2", English)
+}
+
+// Register makes a stemmer available by the provided name, so it can be picked
+// with Lookup, e.g., from a configuration file. If Register is called twice with
+// the same name or if s is nil, it panics.
+func Register(name string, s Stemmer) {
+	stemmersMu.Lock()
+	defer stemmersMu.Unlock()
+
+	if s == nil {
+		panic("porter2: Register stemmer is nil")
+	}
+
+	if _, dup := stemmers[name]; dup {
+		panic("porter2: Register called twice for stemmer " + name)
+	}
+
+	stemmers[name] = s
+}
+
+// Lookup returns the stemmer registered with the provided name.
+func Lookup(name string) (Stemmer, error) {
+	stemmersMu.RLock()
+	s, ok := stemmers[name]
+	stemmersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("porter2: unknown stemmer %q (forgotten import?)", name)
+	}
+
+	return s, nil
+}
+
+// Stemmers returns a sorted list of the names of the registered stemmers.
+func Stemmers() []string {
+	stemmersMu.RLock()
+	defer stemmersMu.RUnlock()
+
+	names := make([]string, 0, len(stemmers))
+	for name := range stemmers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
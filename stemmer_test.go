@@ -0,0 +1,60 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type upperStemmer struct{}
+
+func (upperStemmer) Stem(s string) string {
+	return strings.ToUpper(s)
+}
+
+func (upperStemmer) AppendStem(dst, word []byte) []byte {
+	return append(dst, strings.ToUpper(string(word))...)
+}
+
+func TestStemmerLookup(t *testing.T) {
+	for _, name := range []string{"english", "porter2"} {
+		s, err := Lookup(name)
+		assert.NoError(t, err)
+		assert.Equal(t, English, s)
+	}
+
+	_, err := Lookup("klingon")
+	assert.Error(t, err)
+}
+
+func TestStemmerRegister(t *testing.T) {
+	Register("test-upper", upperStemmer{})
+	defer func() {
+		stemmersMu.Lock()
+		delete(stemmers, "test-upper")
+		stemmersMu.Unlock()
+	}()
+
+	s, err := Lookup("test-upper")
+	assert.NoError(t, err)
+	assert.Equal(t, "SEAWEED", s.Stem("seaweed"))
+	assert.Contains(t, Stemmers(), "test-upper")
+
+	assert.Panics(t, func() { Register("test-upper", upperStemmer{}) })
+	assert.Panics(t, func() { Register("test-nil", nil) })
+}
@@ -0,0 +1,1641 @@
+// Code generated by suffixfsm from step0.txt, step1a.txt, step1b.txt, step2.txt, step3.txt, step4.txt. DO NOT EDIT.
+
+package porter2
+
+// Search for the longest among the suffixes, and remove if found.
+// '
+// 's
+// 's'
+func step0[T letter](rs []T) []T {
+	l := len(rs)
+	m, f := suffix0(rs)
+
+	switch f {
+	case 1, 3, 5:
+		// ' - final
+		// 's - final
+		// 's' - final
+		rs = rs[:l-m]
+	}
+
+	return rs
+}
+
+// suffix0 runs the state machine for step0 over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix0[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case '\'':
+				s = 1
+				m = 1
+				f = 1
+				// ' - final
+			case 's':
+				s = 2
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 's':
+				s = 4
+			default:
+				break loop
+			}
+		case 2:
+			switch r {
+			case '\'':
+				s = 3
+				m = 2
+				f = 3
+				// 's - final
+			default:
+				break loop
+			}
+		case 4:
+			switch r {
+			case '\'':
+				s = 5
+				m = 3
+				f = 5
+				// 's' - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
+
+// Search for the longest suffix among the suffixes, and perform the action indicated.
+//
+//	sses : replace by ss
+//	 ied : replace by i if preceded by more than one letter, otherwise by ie (so ties -> tie, cries -> cri)
+//	 ies : replace by i if preceded by more than one letter, otherwise by ie (so ties -> tie, cries -> cri)
+//	   s : delete if the preceding word part contains a vowel not immediately before the s (so gas and this retain the s, gaps and kiwis lose it)
+//	  us : do nothing
+//	  ss : do nothing
+func step1a[T letter](rs []T) []T {
+	l := len(rs)
+	m, f := suffix1a(rs)
+
+	switch f {
+	case 1:
+		// s - final
+		if hasVowelBeforeLast(rs[:l-m]) {
+			rs = rs[:l-m]
+		}
+
+	case 4:
+		// sses - final
+		rs = rs[:l-2]
+
+	case 7, 8:
+		// ied - final
+		// ies - final
+		rs = rs[:l-m]
+		rs = ie(rs)
+
+	case 9, 10:
+		// us - final
+		// ss - final
+		// do nothing
+	}
+
+	return rs
+}
+
+// suffix1a runs the state machine for step1a over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix1a[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case 's':
+				s = 1
+				m = 1
+				f = 1
+				// s - final
+			case 'd':
+				s = 5
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 'e':
+				s = 2
+			case 'u':
+				s = 9
+				m = 2
+				f = 9
+				// us - final
+			case 's':
+				s = 10
+				m = 2
+				f = 10
+				// ss - final
+			default:
+				break loop
+			}
+		case 2:
+			switch r {
+			case 's':
+				s = 3
+			case 'i':
+				s = 8
+				m = 3
+				f = 8
+				// ies - final
+			default:
+				break loop
+			}
+		case 3:
+			switch r {
+			case 's':
+				s = 4
+				m = 4
+				f = 4
+				// sses - final
+			default:
+				break loop
+			}
+		case 5:
+			switch r {
+			case 'e':
+				s = 6
+			default:
+				break loop
+			}
+		case 6:
+			switch r {
+			case 'i':
+				s = 7
+				m = 3
+				f = 7
+				// ied - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
+
+// Search for the longest suffix among the suffixes, and perform the action indicated.
+// 1. ingly -> see note below
+// 2. eedly -> replace by ee if in R1
+// 3.  edly -> see note below
+// 4.   ing -> see note below
+// 5.   eed -> replace by ee if in R1
+// 6.    ed -> see note below
+//
+// Note: delete if the preceding word part contains a vowel, and after the deletion:
+//
+//	if the word ends at, bl or iz add e (so luxuriat -> luxuriate), or
+//	if the word ends with a double remove the last letter (so hopp -> hop), or
+//	if the word is short, add e (so hop -> hope)
+func step1b[T letter](rs []T, r1 int) []T {
+	l := len(rs)
+	m, f := suffix1b(rs)
+
+	switch f {
+	case 5, 7, 11, 13:
+		// ingly - final
+		// edly - final
+		// ing - final
+		// ed - final
+		if hasVowel(rs[:l-m]) {
+			rs = rs[:l-m]
+			rs = fixEnding(rs, r1)
+		}
+
+	case 8:
+		// eedly - final
+		if l-m >= r1 {
+			rs = rs[:l-3]
+		}
+
+	case 14:
+		// eed - final
+		if l-m >= r1 {
+			rs = rs[:l-1]
+		}
+	}
+
+	return rs
+}
+
+// suffix1b runs the state machine for step1b over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix1b[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case 'y':
+				s = 1
+			case 'g':
+				s = 9
+			case 'd':
+				s = 12
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 'l':
+				s = 2
+			default:
+				break loop
+			}
+		case 2:
+			switch r {
+			case 'g':
+				s = 3
+			case 'd':
+				s = 6
+			default:
+				break loop
+			}
+		case 3:
+			switch r {
+			case 'n':
+				s = 4
+			default:
+				break loop
+			}
+		case 4:
+			switch r {
+			case 'i':
+				s = 5
+				m = 5
+				f = 5
+				// ingly - final
+			default:
+				break loop
+			}
+		case 6:
+			switch r {
+			case 'e':
+				s = 7
+				m = 4
+				f = 7
+				// edly - final
+			default:
+				break loop
+			}
+		case 7:
+			switch r {
+			case 'e':
+				s = 8
+				m = 5
+				f = 8
+				// eedly - final
+			default:
+				break loop
+			}
+		case 9:
+			switch r {
+			case 'n':
+				s = 10
+			default:
+				break loop
+			}
+		case 10:
+			switch r {
+			case 'i':
+				s = 11
+				m = 3
+				f = 11
+				// ing - final
+			default:
+				break loop
+			}
+		case 12:
+			switch r {
+			case 'e':
+				s = 13
+				m = 2
+				f = 13
+				// ed - final
+			default:
+				break loop
+			}
+		case 13:
+			switch r {
+			case 'e':
+				s = 14
+				m = 3
+				f = 14
+				// eed - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
+
+// Search for the longest among the following suffixes, and, if found and in R1,
+// perform the action indicated.
+//
+//  1. tional -> replace by tion
+//  2. enci -> replace by ence
+//  3. anci -> replace by ance
+//  4. abli -> replace by able
+//  5. entli -> replace by ent
+//  6. izer -> replace by ize
+//  7. ization -> replace by ize
+//  8. ational -> replace by ate
+//  9. ation -> replace by ate
+//  10. ator -> replace by ate
+//  11. alism -> replace by al
+//  12. aliti -> replace by al
+//  13. alli -> replace by al
+//  14. fulness -> replace by ful
+//  15. ousli -> replace by ous
+//  16. ousness -> replace by ous
+//  17. iveness -> replace by ive
+//  18. iviti -> replace by ive
+//  19. biliti -> replace by ble
+//  20. bli -> replace by ble
+//  21. ogi -> replace by og if preceded by l
+//  22. fulli -> replace by ful
+//  23. lessli -> replace by less
+//  24. li -> delete if preceded by a valid li-ending
+func step2[T letter](rs []T, r1 int) []T {
+	l := len(rs)
+	m, f := suffix2(rs)
+
+	switch f {
+	case 7, 10, 13:
+		// fulness - final
+		// ousness - final
+		// iveness - final
+		if l-m >= r1 {
+			rs = rs[:l-4]
+		}
+
+	case 19, 38, 41, 43, 53, 68:
+		// tional - final
+		// lessli - final
+		// fulli - final
+		// ousli - final
+		// entli - final
+		// alli - final
+		if l-m >= r1 {
+			rs = rs[:l-2]
+		}
+
+	case 20, 27:
+		// ational - final
+		// ization - final
+		if l-m >= r1 {
+			rs = append(rs[:l-5], 'e')
+		}
+
+	case 25, 45:
+		// ation - final
+		// iviti - final
+		if l-m >= r1 {
+			rs = append(rs[:l-3], 'e')
+		}
+
+	case 33:
+		// biliti - final
+		if l-m >= r1 {
+			rs = append(rs[:l-5], 'l', 'e')
+		}
+
+	case 34:
+		// li - final
+		if l-m >= r1 && l > m {
+			switch rs[l-m-1] {
+			case 'c', 'd', 'e', 'g', 'h', 'k', 'm', 'n', 'r', 't':
+				rs = rs[:l-m]
+			}
+		}
+
+	case 50, 54:
+		// alism - final
+		// aliti - final
+		if l-m >= r1 {
+			rs = rs[:l-3]
+		}
+
+	case 57, 58, 59, 60:
+		// enci - final
+		// anci - final
+		// bli - final
+		// abli - final
+		if l-m >= r1 {
+			rs = append(rs[:l-1], 'e')
+		}
+
+	case 64:
+		// izer - final
+		if l-m >= r1 {
+			rs = rs[:l-1]
+		}
+
+	case 67:
+		// ator - final
+		if l-m >= r1 {
+			rs = append(rs[:l-2], 'e')
+		}
+
+	case 70:
+		// ogi - final
+		if l-m >= r1 && l > m {
+			switch rs[l-m-1] {
+			case 'l':
+				rs = rs[:l-1]
+			}
+		}
+	}
+
+	return rs
+}
+
+// suffix2 runs the state machine for step2 over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix2[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case 's':
+				s = 1
+			case 'l':
+				s = 14
+			case 'n':
+				s = 21
+			case 'i':
+				s = 28
+			case 'm':
+				s = 46
+			case 'r':
+				s = 61
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 's':
+				s = 2
+			default:
+				break loop
+			}
+		case 2:
+			switch r {
+			case 'e':
+				s = 3
+			default:
+				break loop
+			}
+		case 3:
+			switch r {
+			case 'n':
+				s = 4
+			default:
+				break loop
+			}
+		case 4:
+			switch r {
+			case 'l':
+				s = 5
+			case 's':
+				s = 8
+			case 'e':
+				s = 11
+			default:
+				break loop
+			}
+		case 5:
+			switch r {
+			case 'u':
+				s = 6
+			default:
+				break loop
+			}
+		case 6:
+			switch r {
+			case 'f':
+				s = 7
+				m = 7
+				f = 7
+				// fulness - final
+			default:
+				break loop
+			}
+		case 8:
+			switch r {
+			case 'u':
+				s = 9
+			default:
+				break loop
+			}
+		case 9:
+			switch r {
+			case 'o':
+				s = 10
+				m = 7
+				f = 10
+				// ousness - final
+			default:
+				break loop
+			}
+		case 11:
+			switch r {
+			case 'v':
+				s = 12
+			default:
+				break loop
+			}
+		case 12:
+			switch r {
+			case 'i':
+				s = 13
+				m = 7
+				f = 13
+				// iveness - final
+			default:
+				break loop
+			}
+		case 14:
+			switch r {
+			case 'a':
+				s = 15
+			default:
+				break loop
+			}
+		case 15:
+			switch r {
+			case 'n':
+				s = 16
+			default:
+				break loop
+			}
+		case 16:
+			switch r {
+			case 'o':
+				s = 17
+			default:
+				break loop
+			}
+		case 17:
+			switch r {
+			case 'i':
+				s = 18
+			default:
+				break loop
+			}
+		case 18:
+			switch r {
+			case 't':
+				s = 19
+				m = 6
+				f = 19
+				// tional - final
+			default:
+				break loop
+			}
+		case 19:
+			switch r {
+			case 'a':
+				s = 20
+				m = 7
+				f = 20
+				// ational - final
+			default:
+				break loop
+			}
+		case 21:
+			switch r {
+			case 'o':
+				s = 22
+			default:
+				break loop
+			}
+		case 22:
+			switch r {
+			case 'i':
+				s = 23
+			default:
+				break loop
+			}
+		case 23:
+			switch r {
+			case 't':
+				s = 24
+			default:
+				break loop
+			}
+		case 24:
+			switch r {
+			case 'a':
+				s = 25
+				m = 5
+				f = 25
+				// ation - final
+			default:
+				break loop
+			}
+		case 25:
+			switch r {
+			case 'z':
+				s = 26
+			default:
+				break loop
+			}
+		case 26:
+			switch r {
+			case 'i':
+				s = 27
+				m = 7
+				f = 27
+				// ization - final
+			default:
+				break loop
+			}
+		case 28:
+			switch r {
+			case 't':
+				s = 29
+			case 'l':
+				s = 34
+				m = 2
+				f = 34
+				// li - final
+			case 'c':
+				s = 55
+			case 'g':
+				s = 69
+			default:
+				break loop
+			}
+		case 29:
+			switch r {
+			case 'i':
+				s = 30
+			default:
+				break loop
+			}
+		case 30:
+			switch r {
+			case 'l':
+				s = 31
+			case 'v':
+				s = 44
+			default:
+				break loop
+			}
+		case 31:
+			switch r {
+			case 'i':
+				s = 32
+			case 'a':
+				s = 54
+				m = 5
+				f = 54
+				// aliti - final
+			default:
+				break loop
+			}
+		case 32:
+			switch r {
+			case 'b':
+				s = 33
+				m = 6
+				f = 33
+				// biliti - final
+			default:
+				break loop
+			}
+		case 34:
+			switch r {
+			case 's':
+				s = 35
+			case 'l':
+				s = 39
+			case 't':
+				s = 51
+			case 'b':
+				s = 59
+				m = 3
+				f = 59
+				// bli - final
+			default:
+				break loop
+			}
+		case 35:
+			switch r {
+			case 's':
+				s = 36
+			case 'u':
+				s = 42
+			default:
+				break loop
+			}
+		case 36:
+			switch r {
+			case 'e':
+				s = 37
+			default:
+				break loop
+			}
+		case 37:
+			switch r {
+			case 'l':
+				s = 38
+				m = 6
+				f = 38
+				// lessli - final
+			default:
+				break loop
+			}
+		case 39:
+			switch r {
+			case 'u':
+				s = 40
+			case 'a':
+				s = 68
+				m = 4
+				f = 68
+				// alli - final
+			default:
+				break loop
+			}
+		case 40:
+			switch r {
+			case 'f':
+				s = 41
+				m = 5
+				f = 41
+				// fulli - final
+			default:
+				break loop
+			}
+		case 42:
+			switch r {
+			case 'o':
+				s = 43
+				m = 5
+				f = 43
+				// ousli - final
+			default:
+				break loop
+			}
+		case 44:
+			switch r {
+			case 'i':
+				s = 45
+				m = 5
+				f = 45
+				// iviti - final
+			default:
+				break loop
+			}
+		case 46:
+			switch r {
+			case 's':
+				s = 47
+			default:
+				break loop
+			}
+		case 47:
+			switch r {
+			case 'i':
+				s = 48
+			default:
+				break loop
+			}
+		case 48:
+			switch r {
+			case 'l':
+				s = 49
+			default:
+				break loop
+			}
+		case 49:
+			switch r {
+			case 'a':
+				s = 50
+				m = 5
+				f = 50
+				// alism - final
+			default:
+				break loop
+			}
+		case 51:
+			switch r {
+			case 'n':
+				s = 52
+			default:
+				break loop
+			}
+		case 52:
+			switch r {
+			case 'e':
+				s = 53
+				m = 5
+				f = 53
+				// entli - final
+			default:
+				break loop
+			}
+		case 55:
+			switch r {
+			case 'n':
+				s = 56
+			default:
+				break loop
+			}
+		case 56:
+			switch r {
+			case 'e':
+				s = 57
+				m = 4
+				f = 57
+				// enci - final
+			case 'a':
+				s = 58
+				m = 4
+				f = 58
+				// anci - final
+			default:
+				break loop
+			}
+		case 59:
+			switch r {
+			case 'a':
+				s = 60
+				m = 4
+				f = 60
+				// abli - final
+			default:
+				break loop
+			}
+		case 61:
+			switch r {
+			case 'e':
+				s = 62
+			case 'o':
+				s = 65
+			default:
+				break loop
+			}
+		case 62:
+			switch r {
+			case 'z':
+				s = 63
+			default:
+				break loop
+			}
+		case 63:
+			switch r {
+			case 'i':
+				s = 64
+				m = 4
+				f = 64
+				// izer - final
+			default:
+				break loop
+			}
+		case 65:
+			switch r {
+			case 't':
+				s = 66
+			default:
+				break loop
+			}
+		case 66:
+			switch r {
+			case 'a':
+				s = 67
+				m = 4
+				f = 67
+				// ator - final
+			default:
+				break loop
+			}
+		case 69:
+			switch r {
+			case 'o':
+				s = 70
+				m = 3
+				f = 70
+				// ogi - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
+
+// Search for the longest among the following suffixes, and, if found and in R1,
+// perform the action indicated.
+//
+// 1.  tional -> replace by tion
+// 2. ational -> replace by ate
+// 3.   alize -> replace by al
+// 4.   icate -> replace by ic
+// 5.   iciti -> replace by ic
+// 6.    ical -> replace by ic
+// 7.     ful -> delete
+// 8.    ness -> delete
+// 9.   ative -> delete if in R2
+func step3[T letter](rs []T, r1, r2 int) []T {
+	l := len(rs)
+	m, f := suffix3(rs)
+
+	switch f {
+	case 6, 23:
+		// tional - final
+		// ical - final
+		if l-m >= r1 {
+			rs = rs[:l-2]
+		}
+
+	case 7:
+		// ational - final
+		if l-m >= r1 {
+			rs = append(rs[:l-5], 'e')
+		}
+
+	case 12, 16, 21:
+		// alize - final
+		// icate - final
+		// iciti - final
+		if l-m >= r1 {
+			rs = rs[:l-3]
+		}
+
+	case 25, 29:
+		// ful - final
+		// ness - final
+		if l-m >= r1 {
+			rs = rs[:l-m]
+		}
+
+	case 33:
+		// ative - final
+		if l-m >= r2 {
+			rs = rs[:l-m]
+		}
+	}
+
+	return rs
+}
+
+// suffix3 runs the state machine for step3 over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix3[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case 'l':
+				s = 1
+			case 'e':
+				s = 8
+			case 'i':
+				s = 17
+			case 's':
+				s = 26
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 'a':
+				s = 2
+			case 'u':
+				s = 24
+			default:
+				break loop
+			}
+		case 2:
+			switch r {
+			case 'n':
+				s = 3
+			case 'c':
+				s = 22
+			default:
+				break loop
+			}
+		case 3:
+			switch r {
+			case 'o':
+				s = 4
+			default:
+				break loop
+			}
+		case 4:
+			switch r {
+			case 'i':
+				s = 5
+			default:
+				break loop
+			}
+		case 5:
+			switch r {
+			case 't':
+				s = 6
+				m = 6
+				f = 6
+				// tional - final
+			default:
+				break loop
+			}
+		case 6:
+			switch r {
+			case 'a':
+				s = 7
+				m = 7
+				f = 7
+				// ational - final
+			default:
+				break loop
+			}
+		case 8:
+			switch r {
+			case 'z':
+				s = 9
+			case 't':
+				s = 13
+			case 'v':
+				s = 30
+			default:
+				break loop
+			}
+		case 9:
+			switch r {
+			case 'i':
+				s = 10
+			default:
+				break loop
+			}
+		case 10:
+			switch r {
+			case 'l':
+				s = 11
+			default:
+				break loop
+			}
+		case 11:
+			switch r {
+			case 'a':
+				s = 12
+				m = 5
+				f = 12
+				// alize - final
+			default:
+				break loop
+			}
+		case 13:
+			switch r {
+			case 'a':
+				s = 14
+			default:
+				break loop
+			}
+		case 14:
+			switch r {
+			case 'c':
+				s = 15
+			default:
+				break loop
+			}
+		case 15:
+			switch r {
+			case 'i':
+				s = 16
+				m = 5
+				f = 16
+				// icate - final
+			default:
+				break loop
+			}
+		case 17:
+			switch r {
+			case 't':
+				s = 18
+			default:
+				break loop
+			}
+		case 18:
+			switch r {
+			case 'i':
+				s = 19
+			default:
+				break loop
+			}
+		case 19:
+			switch r {
+			case 'c':
+				s = 20
+			default:
+				break loop
+			}
+		case 20:
+			switch r {
+			case 'i':
+				s = 21
+				m = 5
+				f = 21
+				// iciti - final
+			default:
+				break loop
+			}
+		case 22:
+			switch r {
+			case 'i':
+				s = 23
+				m = 4
+				f = 23
+				// ical - final
+			default:
+				break loop
+			}
+		case 24:
+			switch r {
+			case 'f':
+				s = 25
+				m = 3
+				f = 25
+				// ful - final
+			default:
+				break loop
+			}
+		case 26:
+			switch r {
+			case 's':
+				s = 27
+			default:
+				break loop
+			}
+		case 27:
+			switch r {
+			case 'e':
+				s = 28
+			default:
+				break loop
+			}
+		case 28:
+			switch r {
+			case 'n':
+				s = 29
+				m = 4
+				f = 29
+				// ness - final
+			default:
+				break loop
+			}
+		case 30:
+			switch r {
+			case 'i':
+				s = 31
+			default:
+				break loop
+			}
+		case 31:
+			switch r {
+			case 't':
+				s = 32
+			default:
+				break loop
+			}
+		case 32:
+			switch r {
+			case 'a':
+				s = 33
+				m = 5
+				f = 33
+				// ative - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
+
+// Search for the longest among the following suffixes, and, if found and in R2,
+// perform the action indicated.
+//
+//  1. able -> delete
+//  2. al -> delete
+//  3. ance -> delete
+//  4. ant -> delete
+//  5. ate -> delete
+//  6. ement -> delete
+//  7. ence -> delete
+//  8. ent -> delete
+//  9. er -> delete
+//  10. ible -> delete
+//  11. ic -> delete
+//  12. ism -> delete
+//  13. iti -> delete
+//  14. ive -> delete
+//  15. ize -> delete
+//  16. ment -> delete
+//  17. ous -> delete
+//  18. ion -> delete if preceded by s or t
+func step4[T letter](rs []T, r2 int) []T {
+	l := len(rs)
+	m, f := suffix4(rs)
+
+	switch f {
+	case 4, 6, 9, 12, 14, 15, 16, 17, 18, 20, 21, 23, 26, 29, 31, 33, 36:
+		// able - final
+		// al - final
+		// ance - final
+		// ant - final
+		// ate - final
+		// ent - final
+		// ment - final
+		// ement - final
+		// ence - final
+		// er - final
+		// ible - final
+		// ic - final
+		// ism - final
+		// iti - final
+		// ive - final
+		// ize - final
+		// ous - final
+		if l-m >= r2 {
+			rs = rs[:l-m]
+		}
+
+	case 39:
+		// ion - final
+		if l-m >= r2 && l > m {
+			switch rs[l-m-1] {
+			case 's', 't':
+				rs = rs[:l-m]
+			}
+		}
+	}
+
+	return rs
+}
+
+// suffix4 runs the state machine for step4 over rs. It returns the length
+// of the longest suffix found and its end state, or 0, 0 if none is found.
+func suffix4[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+		r T             // current rune
+	)
+
+loop:
+	for i := 0; i < l; i++ {
+		r = rs[l-i-1]
+
+		switch s {
+
+		case 0:
+			switch r {
+			case 'e':
+				s = 1
+			case 'l':
+				s = 5
+			case 't':
+				s = 10
+			case 'r':
+				s = 19
+			case 'c':
+				s = 22
+			case 'm':
+				s = 24
+			case 'i':
+				s = 27
+			case 's':
+				s = 34
+			case 'n':
+				s = 37
+			default:
+				break loop
+			}
+		case 1:
+			switch r {
+			case 'l':
+				s = 2
+			case 'c':
+				s = 7
+			case 't':
+				s = 13
+			case 'v':
+				s = 30
+			case 'z':
+				s = 32
+			default:
+				break loop
+			}
+		case 2:
+			switch r {
+			case 'b':
+				s = 3
+			default:
+				break loop
+			}
+		case 3:
+			switch r {
+			case 'a':
+				s = 4
+				m = 4
+				f = 4
+				// able - final
+			case 'i':
+				s = 21
+				m = 4
+				f = 21
+				// ible - final
+			default:
+				break loop
+			}
+		case 5:
+			switch r {
+			case 'a':
+				s = 6
+				m = 2
+				f = 6
+				// al - final
+			default:
+				break loop
+			}
+		case 7:
+			switch r {
+			case 'n':
+				s = 8
+			default:
+				break loop
+			}
+		case 8:
+			switch r {
+			case 'a':
+				s = 9
+				m = 4
+				f = 9
+				// ance - final
+			case 'e':
+				s = 18
+				m = 4
+				f = 18
+				// ence - final
+			default:
+				break loop
+			}
+		case 10:
+			switch r {
+			case 'n':
+				s = 11
+			default:
+				break loop
+			}
+		case 11:
+			switch r {
+			case 'a':
+				s = 12
+				m = 3
+				f = 12
+				// ant - final
+			case 'e':
+				s = 15
+				m = 3
+				f = 15
+				// ent - final
+			default:
+				break loop
+			}
+		case 13:
+			switch r {
+			case 'a':
+				s = 14
+				m = 3
+				f = 14
+				// ate - final
+			default:
+				break loop
+			}
+		case 15:
+			switch r {
+			case 'm':
+				s = 16
+				m = 4
+				f = 16
+				// ment - final
+			default:
+				break loop
+			}
+		case 16:
+			switch r {
+			case 'e':
+				s = 17
+				m = 5
+				f = 17
+				// ement - final
+			default:
+				break loop
+			}
+		case 19:
+			switch r {
+			case 'e':
+				s = 20
+				m = 2
+				f = 20
+				// er - final
+			default:
+				break loop
+			}
+		case 22:
+			switch r {
+			case 'i':
+				s = 23
+				m = 2
+				f = 23
+				// ic - final
+			default:
+				break loop
+			}
+		case 24:
+			switch r {
+			case 's':
+				s = 25
+			default:
+				break loop
+			}
+		case 25:
+			switch r {
+			case 'i':
+				s = 26
+				m = 3
+				f = 26
+				// ism - final
+			default:
+				break loop
+			}
+		case 27:
+			switch r {
+			case 't':
+				s = 28
+			default:
+				break loop
+			}
+		case 28:
+			switch r {
+			case 'i':
+				s = 29
+				m = 3
+				f = 29
+				// iti - final
+			default:
+				break loop
+			}
+		case 30:
+			switch r {
+			case 'i':
+				s = 31
+				m = 3
+				f = 31
+				// ive - final
+			default:
+				break loop
+			}
+		case 32:
+			switch r {
+			case 'i':
+				s = 33
+				m = 3
+				f = 33
+				// ize - final
+			default:
+				break loop
+			}
+		case 34:
+			switch r {
+			case 'u':
+				s = 35
+			default:
+				break loop
+			}
+		case 35:
+			switch r {
+			case 'o':
+				s = 36
+				m = 3
+				f = 36
+				// ous - final
+			default:
+				break loop
+			}
+		case 37:
+			switch r {
+			case 'o':
+				s = 38
+			default:
+				break loop
+			}
+		case 38:
+			switch r {
+			case 'i':
+				s = 39
+				m = 3
+				f = 39
+				// ion - final
+			default:
+				break loop
+			}
+		default:
+			break loop
+		}
+	}
+
+	return m, f
+}
@@ -0,0 +1,829 @@
+// Code generated by suffixfsm from step0.txt, step1a.txt, step1b.txt, step2.txt, step3.txt, step4.txt. DO NOT EDIT.
+
+package porter2
+
+// Search for the longest among the suffixes, and remove if found.
+// '
+// 's
+// 's'
+func step0Table[T letter](rs []T) []T {
+	l := len(rs)
+	m, f := suffix0Table(rs)
+
+	switch f {
+	case 1, 3:
+		// ' - final
+		// 's - final
+		// 's' - final
+		rs = rs[:l-m]
+	}
+
+	return rs
+}
+
+// suffix0Table runs the state machine for step0Table over rs, using the transition
+// tables suffix0TableNext and suffix0TableFinal. It returns the length of the longest
+// suffix found and its end state, or 0, 0 if none is found.
+func suffix0Table[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+	)
+
+	for i := 0; i < l; i++ {
+		r := rs[l-i-1]
+		if rune(r) > 0xff {
+			break
+		}
+
+		s = int(suffix0TableNext[s][suffix0TableClass[byte(r)]])
+		if s == 0 {
+			break
+		}
+
+		if suffix0TableFinal[s] {
+			m, f = i+1, s
+		}
+	}
+
+	return m, f
+}
+
+// suffix0TableClass is the column of each byte in suffix0TableNext, or 0 if the byte
+// isn't in any suffix.
+var suffix0TableClass = [256]uint8{
+	'\'': 1,
+	's':  2,
+}
+
+// suffix0TableNext is the next state for each state and letter, or 0 if there's none.
+var suffix0TableNext = [4][3]uint8{
+	{0, 1, 2}, // 0
+	{0, 0, 2}, // 1
+	{0, 3, 0}, // 2
+	{0, 0, 0}, // 3
+}
+
+// suffix0TableFinal tells which states are final.
+var suffix0TableFinal = [4]bool{
+	1: true, // '
+	3: true, // 's, 's'
+}
+
+// Search for the longest suffix among the suffixes, and perform the action indicated.
+//
+//	sses : replace by ss
+//	 ied : replace by i if preceded by more than one letter, otherwise by ie (so ties -> tie, cries -> cri)
+//	 ies : replace by i if preceded by more than one letter, otherwise by ie (so ties -> tie, cries -> cri)
+//	   s : delete if the preceding word part contains a vowel not immediately before the s (so gas and this retain the s, gaps and kiwis lose it)
+//	  us : do nothing
+//	  ss : do nothing
+func step1aTable[T letter](rs []T) []T {
+	l := len(rs)
+	m, f := suffix1aTable(rs)
+
+	switch f {
+	case 1:
+		// s - final
+		if hasVowelBeforeLast(rs[:l-m]) {
+			rs = rs[:l-m]
+		}
+
+	case 4:
+		// sses - final
+		rs = rs[:l-2]
+
+	case 7:
+		// ied - final
+		// ies - final
+		rs = rs[:l-m]
+		rs = ie(rs)
+
+	case 8:
+		// us - final
+		// ss - final
+		// do nothing
+	}
+
+	return rs
+}
+
+// suffix1aTable runs the state machine for step1aTable over rs, using the transition
+// tables suffix1aTableNext and suffix1aTableFinal. It returns the length of the longest
+// suffix found and its end state, or 0, 0 if none is found.
+func suffix1aTable[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+	)
+
+	for i := 0; i < l; i++ {
+		r := rs[l-i-1]
+		if rune(r) > 0xff {
+			break
+		}
+
+		s = int(suffix1aTableNext[s][suffix1aTableClass[byte(r)]])
+		if s == 0 {
+			break
+		}
+
+		if suffix1aTableFinal[s] {
+			m, f = i+1, s
+		}
+	}
+
+	return m, f
+}
+
+// suffix1aTableClass is the column of each byte in suffix1aTableNext, or 0 if the byte
+// isn't in any suffix.
+var suffix1aTableClass = [256]uint8{
+	'd': 1,
+	'e': 2,
+	'i': 3,
+	's': 4,
+	'u': 5,
+}
+
+// suffix1aTableNext is the next state for each state and letter, or 0 if there's none.
+var suffix1aTableNext = [9][6]uint8{
+	{0, 5, 0, 0, 1, 0}, // 0
+	{0, 0, 2, 0, 8, 8}, // 1
+	{0, 0, 0, 7, 3, 0}, // 2
+	{0, 0, 0, 0, 4, 0}, // 3
+	{0, 0, 0, 0, 0, 0}, // 4
+	{0, 0, 6, 0, 0, 0}, // 5
+	{0, 0, 0, 7, 0, 0}, // 6
+	{0, 0, 0, 0, 0, 0}, // 7
+	{0, 0, 0, 0, 0, 0}, // 8
+}
+
+// suffix1aTableFinal tells which states are final.
+var suffix1aTableFinal = [9]bool{
+	1: true, // s
+	4: true, // sses
+	7: true, // ied, ies
+	8: true, // us, ss
+}
+
+// Search for the longest suffix among the suffixes, and perform the action indicated.
+// 1. ingly -> see note below
+// 2. eedly -> replace by ee if in R1
+// 3.  edly -> see note below
+// 4.   ing -> see note below
+// 5.   eed -> replace by ee if in R1
+// 6.    ed -> see note below
+//
+// Note: delete if the preceding word part contains a vowel, and after the deletion:
+//
+//	if the word ends at, bl or iz add e (so luxuriat -> luxuriate), or
+//	if the word ends with a double remove the last letter (so hopp -> hop), or
+//	if the word is short, add e (so hop -> hope)
+func step1bTable[T letter](rs []T, r1 int) []T {
+	l := len(rs)
+	m, f := suffix1bTable(rs)
+
+	switch f {
+	case 5, 7, 10:
+		// ingly - final
+		// ing - final
+		// edly - final
+		// ed - final
+		if hasVowel(rs[:l-m]) {
+			rs = rs[:l-m]
+			rs = fixEnding(rs, r1)
+		}
+
+	case 8:
+		// eedly - final
+		if l-m >= r1 {
+			rs = rs[:l-3]
+		}
+
+	case 11:
+		// eed - final
+		if l-m >= r1 {
+			rs = rs[:l-1]
+		}
+	}
+
+	return rs
+}
+
+// suffix1bTable runs the state machine for step1bTable over rs, using the transition
+// tables suffix1bTableNext and suffix1bTableFinal. It returns the length of the longest
+// suffix found and its end state, or 0, 0 if none is found.
+func suffix1bTable[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+	)
+
+	for i := 0; i < l; i++ {
+		r := rs[l-i-1]
+		if rune(r) > 0xff {
+			break
+		}
+
+		s = int(suffix1bTableNext[s][suffix1bTableClass[byte(r)]])
+		if s == 0 {
+			break
+		}
+
+		if suffix1bTableFinal[s] {
+			m, f = i+1, s
+		}
+	}
+
+	return m, f
+}
+
+// suffix1bTableClass is the column of each byte in suffix1bTableNext, or 0 if the byte
+// isn't in any suffix.
+var suffix1bTableClass = [256]uint8{
+	'd': 1,
+	'e': 2,
+	'g': 3,
+	'i': 4,
+	'l': 5,
+	'n': 6,
+	'y': 7,
+}
+
+// suffix1bTableNext is the next state for each state and letter, or 0 if there's none.
+var suffix1bTableNext = [12][8]uint8{
+	{0, 9, 0, 3, 0, 0, 0, 1},  // 0
+	{0, 0, 0, 0, 0, 2, 0, 0},  // 1
+	{0, 6, 0, 3, 0, 0, 0, 0},  // 2
+	{0, 0, 0, 0, 0, 0, 4, 0},  // 3
+	{0, 0, 0, 0, 5, 0, 0, 0},  // 4
+	{0, 0, 0, 0, 0, 0, 0, 0},  // 5
+	{0, 0, 7, 0, 0, 0, 0, 0},  // 6
+	{0, 0, 8, 0, 0, 0, 0, 0},  // 7
+	{0, 0, 0, 0, 0, 0, 0, 0},  // 8
+	{0, 0, 10, 0, 0, 0, 0, 0}, // 9
+	{0, 0, 11, 0, 0, 0, 0, 0}, // 10
+	{0, 0, 0, 0, 0, 0, 0, 0},  // 11
+}
+
+// suffix1bTableFinal tells which states are final.
+var suffix1bTableFinal = [12]bool{
+	5:  true, // ingly, ing
+	7:  true, // edly
+	8:  true, // eedly
+	10: true, // ed
+	11: true, // eed
+}
+
+// Search for the longest among the following suffixes, and, if found and in R1,
+// perform the action indicated.
+//
+//  1. tional -> replace by tion
+//  2. enci -> replace by ence
+//  3. anci -> replace by ance
+//  4. abli -> replace by able
+//  5. entli -> replace by ent
+//  6. izer -> replace by ize
+//  7. ization -> replace by ize
+//  8. ational -> replace by ate
+//  9. ation -> replace by ate
+//  10. ator -> replace by ate
+//  11. alism -> replace by al
+//  12. aliti -> replace by al
+//  13. alli -> replace by al
+//  14. fulness -> replace by ful
+//  15. ousli -> replace by ous
+//  16. ousness -> replace by ous
+//  17. iveness -> replace by ive
+//  18. iviti -> replace by ive
+//  19. biliti -> replace by ble
+//  20. bli -> replace by ble
+//  21. ogi -> replace by og if preceded by l
+//  22. fulli -> replace by ful
+//  23. lessli -> replace by less
+//  24. li -> delete if preceded by a valid li-ending
+func step2Table[T letter](rs []T, r1 int) []T {
+	l := len(rs)
+	m, f := suffix2Table(rs)
+
+	switch f {
+	case 7:
+		// fulness - final
+		// ousness - final
+		// iveness - final
+		if l-m >= r1 {
+			rs = rs[:l-4]
+		}
+
+	case 17, 35:
+		// tional - final
+		// lessli - final
+		// fulli - final
+		// ousli - final
+		// entli - final
+		// alli - final
+		if l-m >= r1 {
+			rs = rs[:l-2]
+		}
+
+	case 18:
+		// ational - final
+		// ization - final
+		if l-m >= r1 {
+			rs = append(rs[:l-5], 'e')
+		}
+
+	case 23, 40:
+		// ation - final
+		// iviti - final
+		if l-m >= r1 {
+			rs = append(rs[:l-3], 'e')
+		}
+
+	case 30:
+		// biliti - final
+		if l-m >= r1 {
+			rs = append(rs[:l-5], 'l', 'e')
+		}
+
+	case 31:
+		// li - final
+		if l-m >= r1 && l > m {
+			switch rs[l-m-1] {
+			case 'c', 'd', 'e', 'g', 'h', 'k', 'm', 'n', 'r', 't':
+				rs = rs[:l-m]
+			}
+		}
+
+	case 45:
+		// alism - final
+		// aliti - final
+		if l-m >= r1 {
+			rs = rs[:l-3]
+		}
+
+	case 50, 51:
+		// enci - final
+		// anci - final
+		// abli - final
+		// bli - final
+		if l-m >= r1 {
+			rs = append(rs[:l-1], 'e')
+		}
+
+	case 55:
+		// izer - final
+		if l-m >= r1 {
+			rs = rs[:l-1]
+		}
+
+	case 58:
+		// ator - final
+		if l-m >= r1 {
+			rs = append(rs[:l-2], 'e')
+		}
+
+	case 60:
+		// ogi - final
+		if l-m >= r1 && l > m {
+			switch rs[l-m-1] {
+			case 'l':
+				rs = rs[:l-1]
+			}
+		}
+	}
+
+	return rs
+}
+
+// suffix2Table runs the state machine for step2Table over rs, using the transition
+// tables suffix2TableNext and suffix2TableFinal. It returns the length of the longest
+// suffix found and its end state, or 0, 0 if none is found.
+func suffix2Table[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+	)
+
+	for i := 0; i < l; i++ {
+		r := rs[l-i-1]
+		if rune(r) > 0xff {
+			break
+		}
+
+		s = int(suffix2TableNext[s][suffix2TableClass[byte(r)]])
+		if s == 0 {
+			break
+		}
+
+		if suffix2TableFinal[s] {
+			m, f = i+1, s
+		}
+	}
+
+	return m, f
+}
+
+// suffix2TableClass is the column of each byte in suffix2TableNext, or 0 if the byte
+// isn't in any suffix.
+var suffix2TableClass = [256]uint8{
+	'a': 1,
+	'b': 2,
+	'c': 3,
+	'e': 4,
+	'f': 5,
+	'g': 6,
+	'i': 7,
+	'l': 8,
+	'm': 9,
+	'n': 10,
+	'o': 11,
+	'r': 12,
+	's': 13,
+	't': 14,
+	'u': 15,
+	'v': 16,
+	'z': 17,
+}
+
+// suffix2TableNext is the next state for each state and letter, or 0 if there's none.
+var suffix2TableNext = [61][18]uint8{
+	{0, 0, 0, 0, 0, 0, 0, 25, 12, 41, 19, 0, 52, 1, 0, 0, 0, 0}, // 0
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0},      // 1
+	{0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 2
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0},      // 3
+	{0, 0, 0, 0, 10, 0, 0, 0, 5, 0, 0, 0, 0, 8, 0, 0, 0, 0},     // 4
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 6, 0, 0},      // 5
+	{0, 0, 0, 0, 0, 7, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 6
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 7
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0},      // 8
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 7, 0, 0, 0, 0, 0, 0},      // 9
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 11, 0},     // 10
+	{0, 0, 0, 0, 0, 0, 0, 7, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 11
+	{0, 13, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 12
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 14, 0, 0, 0, 0, 0, 0, 0},     // 13
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 15, 0, 0, 0, 0, 0, 0},     // 14
+	{0, 0, 0, 0, 0, 0, 0, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 15
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 17, 0, 0, 0},     // 16
+	{0, 18, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 17
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 18
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 20, 0, 0, 0, 0, 0, 0},     // 19
+	{0, 0, 0, 0, 0, 0, 0, 21, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 20
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 22, 0, 0, 0},     // 21
+	{0, 23, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 22
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 24},     // 23
+	{0, 0, 0, 0, 0, 0, 0, 18, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 24
+	{0, 0, 0, 48, 0, 0, 59, 0, 31, 0, 0, 0, 0, 0, 26, 0, 0, 0},  // 25
+	{0, 0, 0, 0, 0, 0, 0, 27, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 26
+	{0, 0, 0, 0, 0, 0, 0, 0, 28, 0, 0, 0, 0, 0, 0, 0, 39, 0},    // 27
+	{0, 45, 0, 0, 0, 0, 0, 29, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},    // 28
+	{0, 0, 30, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 29
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 30
+	{0, 0, 51, 0, 0, 0, 0, 0, 36, 0, 0, 0, 0, 32, 46, 0, 0, 0},  // 31
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 33, 0, 38, 0, 0},    // 32
+	{0, 0, 0, 0, 34, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 33
+	{0, 0, 0, 0, 0, 0, 0, 0, 35, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 34
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 35
+	{0, 35, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 37, 0, 0},    // 36
+	{0, 0, 0, 0, 0, 35, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 37
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 35, 0, 0, 0, 0, 0, 0},     // 38
+	{0, 0, 0, 0, 0, 0, 0, 40, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 39
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 40
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 42, 0, 0, 0, 0},     // 41
+	{0, 0, 0, 0, 0, 0, 0, 43, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 42
+	{0, 0, 0, 0, 0, 0, 0, 0, 44, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 43
+	{0, 45, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 44
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 45
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 47, 0, 0, 0, 0, 0, 0, 0},     // 46
+	{0, 0, 0, 0, 35, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 47
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 49, 0, 0, 0, 0, 0, 0, 0},     // 48
+	{0, 50, 0, 0, 50, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},    // 49
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 50
+	{0, 50, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 51
+	{0, 0, 0, 0, 53, 0, 0, 0, 0, 0, 0, 56, 0, 0, 0, 0, 0, 0},    // 52
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 54},     // 53
+	{0, 0, 0, 0, 0, 0, 0, 55, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 54
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 55
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 57, 0, 0, 0},     // 56
+	{0, 58, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // 57
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 58
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 60, 0, 0, 0, 0, 0, 0},     // 59
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 60
+}
+
+// suffix2TableFinal tells which states are final.
+var suffix2TableFinal = [61]bool{
+	7:  true, // fulness, ousness, iveness
+	17: true, // tional
+	18: true, // ational, ization
+	23: true, // ation
+	30: true, // biliti
+	31: true, // li
+	35: true, // lessli, fulli, ousli, entli, alli
+	40: true, // iviti
+	45: true, // alism, aliti
+	50: true, // enci, anci, abli
+	51: true, // bli
+	55: true, // izer
+	58: true, // ator
+	60: true, // ogi
+}
+
+// Search for the longest among the following suffixes, and, if found and in R1,
+// perform the action indicated.
+//
+// 1.  tional -> replace by tion
+// 2. ational -> replace by ate
+// 3.   alize -> replace by al
+// 4.   icate -> replace by ic
+// 5.   iciti -> replace by ic
+// 6.    ical -> replace by ic
+// 7.     ful -> delete
+// 8.    ness -> delete
+// 9.   ative -> delete if in R2
+func step3Table[T letter](rs []T, r1, r2 int) []T {
+	l := len(rs)
+	m, f := suffix3Table(rs)
+
+	switch f {
+	case 6, 19:
+		// tional - final
+		// ical - final
+		if l-m >= r1 {
+			rs = rs[:l-2]
+		}
+
+	case 7:
+		// ational - final
+		if l-m >= r1 {
+			rs = append(rs[:l-5], 'e')
+		}
+
+	case 12:
+		// alize - final
+		// icate - final
+		// iciti - final
+		if l-m >= r1 {
+			rs = rs[:l-3]
+		}
+
+	case 21:
+		// ful - final
+		// ness - final
+		if l-m >= r1 {
+			rs = rs[:l-m]
+		}
+
+	case 28:
+		// ative - final
+		if l-m >= r2 {
+			rs = rs[:l-m]
+		}
+	}
+
+	return rs
+}
+
+// suffix3Table runs the state machine for step3Table over rs, using the transition
+// tables suffix3TableNext and suffix3TableFinal. It returns the length of the longest
+// suffix found and its end state, or 0, 0 if none is found.
+func suffix3Table[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+	)
+
+	for i := 0; i < l; i++ {
+		r := rs[l-i-1]
+		if rune(r) > 0xff {
+			break
+		}
+
+		s = int(suffix3TableNext[s][suffix3TableClass[byte(r)]])
+		if s == 0 {
+			break
+		}
+
+		if suffix3TableFinal[s] {
+			m, f = i+1, s
+		}
+	}
+
+	return m, f
+}
+
+// suffix3TableClass is the column of each byte in suffix3TableNext, or 0 if the byte
+// isn't in any suffix.
+var suffix3TableClass = [256]uint8{
+	'a': 1,
+	'c': 2,
+	'e': 3,
+	'f': 4,
+	'i': 5,
+	'l': 6,
+	'n': 7,
+	'o': 8,
+	's': 9,
+	't': 10,
+	'u': 11,
+	'v': 12,
+	'z': 13,
+}
+
+// suffix3TableNext is the next state for each state and letter, or 0 if there's none.
+var suffix3TableNext = [29][14]uint8{
+	{0, 0, 0, 8, 0, 16, 1, 0, 0, 22, 0, 0, 0, 0}, // 0
+	{0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 20, 0, 0},  // 1
+	{0, 0, 18, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0},  // 2
+	{0, 0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0, 0},   // 3
+	{0, 0, 0, 0, 0, 5, 0, 0, 0, 0, 0, 0, 0, 0},   // 4
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 6, 0, 0, 0},   // 5
+	{0, 7, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},   // 6
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},   // 7
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 13, 0, 25, 9}, // 8
+	{0, 0, 0, 0, 0, 10, 0, 0, 0, 0, 0, 0, 0, 0},  // 9
+	{0, 0, 0, 0, 0, 0, 11, 0, 0, 0, 0, 0, 0, 0},  // 10
+	{0, 12, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},  // 11
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},   // 12
+	{0, 14, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},  // 13
+	{0, 0, 15, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},  // 14
+	{0, 0, 0, 0, 0, 12, 0, 0, 0, 0, 0, 0, 0, 0},  // 15
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 17, 0, 0, 0},  // 16
+	{0, 0, 0, 0, 0, 14, 0, 0, 0, 0, 0, 0, 0, 0},  // 17
+	{0, 0, 0, 0, 0, 19, 0, 0, 0, 0, 0, 0, 0, 0},  // 18
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},   // 19
+	{0, 0, 0, 0, 21, 0, 0, 0, 0, 0, 0, 0, 0, 0},  // 20
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},   // 21
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 23, 0, 0, 0, 0},  // 22
+	{0, 0, 0, 24, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},  // 23
+	{0, 0, 0, 0, 0, 0, 0, 21, 0, 0, 0, 0, 0, 0},  // 24
+	{0, 0, 0, 0, 0, 26, 0, 0, 0, 0, 0, 0, 0, 0},  // 25
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 27, 0, 0, 0},  // 26
+	{0, 28, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},  // 27
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},   // 28
+}
+
+// suffix3TableFinal tells which states are final.
+var suffix3TableFinal = [29]bool{
+	6:  true, // tional
+	7:  true, // ational
+	12: true, // alize, icate, iciti
+	19: true, // ical
+	21: true, // ful, ness
+	28: true, // ative
+}
+
+// Search for the longest among the following suffixes, and, if found and in R2,
+// perform the action indicated.
+//
+//  1. able -> delete
+//  2. al -> delete
+//  3. ance -> delete
+//  4. ant -> delete
+//  5. ate -> delete
+//  6. ement -> delete
+//  7. ence -> delete
+//  8. ent -> delete
+//  9. er -> delete
+//  10. ible -> delete
+//  11. ic -> delete
+//  12. ism -> delete
+//  13. iti -> delete
+//  14. ive -> delete
+//  15. ize -> delete
+//  16. ment -> delete
+//  17. ous -> delete
+//  18. ion -> delete if preceded by s or t
+func step4Table[T letter](rs []T, r2 int) []T {
+	l := len(rs)
+	m, f := suffix4Table(rs)
+
+	switch f {
+	case 4, 10, 11:
+		// able - final
+		// al - final
+		// ance - final
+		// ant - final
+		// ate - final
+		// ement - final
+		// ence - final
+		// er - final
+		// ible - final
+		// ic - final
+		// ism - final
+		// iti - final
+		// ive - final
+		// ize - final
+		// ous - final
+		// ent - final
+		// ment - final
+		if l-m >= r2 {
+			rs = rs[:l-m]
+		}
+
+	case 20:
+		// ion - final
+		if l-m >= r2 && l > m {
+			switch rs[l-m-1] {
+			case 's', 't':
+				rs = rs[:l-m]
+			}
+		}
+	}
+
+	return rs
+}
+
+// suffix4Table runs the state machine for step4Table over rs, using the transition
+// tables suffix4TableNext and suffix4TableFinal. It returns the length of the longest
+// suffix found and its end state, or 0, 0 if none is found.
+func suffix4Table[T letter](rs []T) (int, int) {
+	var (
+		l int = len(rs) // string length
+		m int           // suffix length
+		s int           // state
+		f int           // end state of longgest suffix
+	)
+
+	for i := 0; i < l; i++ {
+		r := rs[l-i-1]
+		if rune(r) > 0xff {
+			break
+		}
+
+		s = int(suffix4TableNext[s][suffix4TableClass[byte(r)]])
+		if s == 0 {
+			break
+		}
+
+		if suffix4TableFinal[s] {
+			m, f = i+1, s
+		}
+	}
+
+	return m, f
+}
+
+// suffix4TableClass is the column of each byte in suffix4TableNext, or 0 if the byte
+// isn't in any suffix.
+var suffix4TableClass = [256]uint8{
+	'a': 1,
+	'b': 2,
+	'c': 3,
+	'e': 4,
+	'i': 5,
+	'l': 6,
+	'm': 7,
+	'n': 8,
+	'o': 9,
+	'r': 10,
+	's': 11,
+	't': 12,
+	'u': 13,
+	'v': 14,
+	'z': 15,
+}
+
+// suffix4TableNext is the next state for each state and letter, or 0 if there's none.
+var suffix4TableNext = [21][16]uint8{
+	{0, 0, 0, 13, 1, 15, 5, 14, 18, 0, 12, 16, 8, 0, 0, 0}, // 0
+	{0, 0, 0, 6, 0, 0, 2, 0, 0, 0, 0, 0, 5, 0, 13, 13},     // 1
+	{0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},       // 2
+	{0, 4, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},       // 3
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},       // 4
+	{0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},       // 5
+	{0, 0, 0, 0, 0, 0, 0, 0, 7, 0, 0, 0, 0, 0, 0, 0},       // 6
+	{0, 4, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},       // 7
+	{0, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0, 0, 0, 0, 0, 0},       // 8
+	{0, 4, 0, 0, 10, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 9
+	{0, 0, 0, 0, 0, 0, 0, 11, 0, 0, 0, 0, 0, 0, 0, 0},      // 10
+	{0, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},       // 11
+	{0, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},       // 12
+	{0, 0, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},       // 13
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 13, 0, 0, 0, 0},      // 14
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 13, 0, 0, 0},      // 15
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 17, 0, 0},      // 16
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0},       // 17
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 19, 0, 0, 0, 0, 0, 0},      // 18
+	{0, 0, 0, 0, 0, 20, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},      // 19
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},       // 20
+}
+
+// suffix4TableFinal tells which states are final.
+var suffix4TableFinal = [21]bool{
+	4:  true, // able, al, ance, ant, ate, ement, ence, er, ible, ic, ism, iti, ive, ize, ous
+	10: true, // ent
+	11: true, // ment
+	20: true, // ion
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"errors"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxWordLen is the length of the longest word StemStrict stems, in runes. Even
+// the longest words in english dictionaries are well short of it. It is part of
+// the API, and doesn't change with the size of the buffers Stem uses.
+const MaxWordLen = 64
+
+// The errors returned by StemStrict for words that aren't english words.
+var (
+	ErrInvalidUTF8   = errors.New("porter2: word is not valid UTF-8")
+	ErrTooLong       = errors.New("porter2: word is longer than MaxWordLen runes")
+	ErrNotAlphabetic = errors.New("porter2: word is not made of Latin letters")
+)
+
+// StemStrict is Stem for words that may not be english words, e.g., the tokens
+// of text from the wild. Rather than stemming anything it's given, it returns
+// ErrInvalidUTF8 if s is not valid UTF-8, ErrTooLong if s is longer than
+// MaxWordLen runes, and ErrNotAlphabetic if s has no letters, or has anything
+// other than letters of the Latin script, combining marks, and apostrophes,
+// e.g., digits, punctuation, or letters of other scripts. Words that stem to
+// nothing, such as two apostrophes and an s, are not alphabetic either.
+func StemStrict(s string) (string, error) {
+	if err := validate(s); err != nil {
+		return "", err
+	}
+
+	stem := Stem(s)
+	if stem == "" {
+		return "", ErrNotAlphabetic
+	}
+
+	return stem, nil
+}
+
+// validate returns the error StemStrict returns for s, if any.
+func validate(s string) error {
+	if !utf8.ValidString(s) {
+		return ErrInvalidUTF8
+	}
+
+	if utf8.RuneCountInString(s) > MaxWordLen {
+		return ErrTooLong
+	}
+
+	letters := false
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			letters = true
+
+		case unicode.Is(unicode.Mn, r), isApostrophe(r):
+
+		default:
+			return ErrNotAlphabetic
+		}
+	}
+
+	if !letters {
+		return ErrNotAlphabetic
+	}
+
+	return nil
+}
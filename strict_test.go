@@ -0,0 +1,80 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStemStrict(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	for i, word := range words {
+		stem, err := StemStrict(word)
+
+		// the vocabulary has a few words that are nothing but apostrophes
+		if strings.Trim(word, "'") == "" {
+			assert.Equal(t, ErrNotAlphabetic, err, word)
+			continue
+		}
+
+		assert.NoError(t, err, word)
+		assert.Equal(t, stems[i], stem, word)
+	}
+
+	for word, expect := range map[string]string{
+		"Seaweed":                       "seawe",
+		"naïvely":                       "naïv",
+		"dog’s":                         "dog",
+		"o'neill's":                     "o'neil",
+		"cafe\u0301":                    "cafe\u0301", // e followed by a combining acute accent
+		"Ångström":                      "ångström",
+		"ox":                            "ox",
+		strings.Repeat("a", MaxWordLen): strings.Repeat("a", MaxWordLen),
+	} {
+		stem, err := StemStrict(word)
+		assert.NoError(t, err, word)
+		assert.Equal(t, expect, stem, word)
+	}
+}
+
+func TestStemStrictErrors(t *testing.T) {
+	for word, expect := range map[string]error{
+		"sea\xffweed":                         ErrInvalidUTF8,
+		"\xc3":                                ErrInvalidUTF8,
+		strings.Repeat("a", MaxWordLen+1):     ErrTooLong,
+		strings.Repeat("é", MaxWordLen) + "s": ErrTooLong,
+		"":                                    ErrNotAlphabetic,
+		"'":                                   ErrNotAlphabetic,
+		"’’":                                  ErrNotAlphabetic,
+		"''s":                                 ErrNotAlphabetic,
+		"2024":                                ErrNotAlphabetic,
+		"mp3s":                                ErrNotAlphabetic,
+		"e-mail":                              ErrNotAlphabetic,
+		"...":                                 ErrNotAlphabetic,
+		"sea weed":                            ErrNotAlphabetic,
+		"дома":                                ErrNotAlphabetic,
+		"日本語":                                 ErrNotAlphabetic,
+		"caféдома":                            ErrNotAlphabetic,
+	} {
+		stem, err := StemStrict(word)
+		assert.Equal(t, "", stem, word)
+		assert.True(t, errors.Is(err, expect), "%q: %v", word, err)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"bufio"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTokenLen is the length in bytes of the longest word a Tokenizer returns.
+// Longer runs of letters are not words, and are skipped.
+const MaxTokenLen = 1024
+
+// Token is a word found by a Tokenizer.
+type Token struct {
+	// Text is the word as it appears in the input, and Stem is its stemmed
+	// version. Both are only valid until the next call to Tokenizer.Next.
+	Text, Stem []byte
+
+	// Start and End are the byte offsets of the word in the input, so that
+	// Text is input[Start:End].
+	Start, End int64
+}
+
+// Tokenizer splits the text read from an io.Reader into words, and stems them.
+// It reads the text as it goes, so documents of any size can be processed in
+// constant memory. Successive calls to Next step through the words, e.g.,
+//
+//	t := porter2.NewTokenizer(r, porter2.English)
+//	for t.Next() {
+//		tok := t.Token()
+//		fmt.Printf("%d-%d %s %s\n", tok.Start, tok.End, tok.Text, tok.Stem)
+//	}
+//	if err := t.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Words are runs of letters, digits and apostrophes, and everything else,
+// including hyphens, separates them. Apostrophes are handled consistently with
+// preclude and step0: U+2018, U+2019 and U+201B count as apostrophes too, those
+// within a word are kept (dog's, rock'n'roll), as is a single one at the end of
+// the word (dogs'), while those at the start of a word are dropped, since they
+// are usually quotes rather than part of the word.
+type Tokenizer struct {
+	r   *bufio.Reader
+	s   Stemmer
+	off int64 // byte offset of the next rune to read
+	tok Token
+	err error
+}
+
+// NewTokenizer returns a Tokenizer that reads text from r, and stems the words
+// with s. If s is nil, English is used.
+func NewTokenizer(r io.Reader, s Stemmer) *Tokenizer {
+	if s == nil {
+		s = English
+	}
+
+	return &Tokenizer{r: bufio.NewReader(r), s: s}
+}
+
+// Next advances the Tokenizer to the next word, which is then available through
+// Token. It returns false when there are no more words, either because the end
+// of the input was reached or because of an error, which Err returns.
+func (t *Tokenizer) Next() bool {
+	if t.err != nil {
+		return false
+	}
+
+	var (
+		text  = t.tok.Text[:0] // word read so far
+		start = int64(-1)      // byte offset of the word, or -1 if there's none yet
+		quote bool             // the word ends with an apostrophe
+		skip  bool             // the word is longer than MaxTokenLen
+	)
+
+	for {
+		r, n, err := t.r.ReadRune()
+		if err != nil {
+			t.err = err
+			break
+		}
+
+		off := t.off
+		t.off += int64(n)
+
+		if isWordRune(r) {
+			if start < 0 {
+				start = off
+			}
+
+			// Once the word is too long, the rest of it is read but not kept,
+			// so the memory used doesn't grow with the length of the run.
+			if !skip {
+				if text = utf8.AppendRune(text, r); len(text) > MaxTokenLen {
+					text, skip = text[:0], true
+				}
+			}
+
+			quote = false
+			continue
+		}
+
+		// An apostrophe is part of the word if there's already a word, and
+		// the word doesn't already end with an apostrophe.
+		if isApostrophe(r) && start >= 0 && !quote {
+			if !skip {
+				text = utf8.AppendRune(text, r)
+			}
+			quote = true
+			continue
+		}
+
+		// Anything else ends the word, if there's one.
+		if start < 0 {
+			continue
+		}
+
+		if !skip {
+			break
+		}
+
+		text, start, quote, skip = text[:0], -1, false, false
+	}
+
+	if start < 0 || skip {
+		t.tok.Text = text
+		return false
+	}
+
+	t.tok = Token{
+		Text:  text,
+		Stem:  t.s.AppendStem(t.tok.Stem[:0], text),
+		Start: start,
+		End:   start + int64(len(text)),
+	}
+
+	return true
+}
+
+// Token returns the word found by the most recent call to Next.
+func (t *Tokenizer) Token() Token {
+	return t.tok
+}
+
+// Err returns the first error that was encountered by the Tokenizer, other than io.EOF.
+func (t *Tokenizer) Err() error {
+	if t.err == io.EOF {
+		return nil
+	}
+
+	return t.err
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isApostrophe(r rune) bool {
+	switch r {
+	case '\'', '\u2018', '\u2019', '\u201B':
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var tokenizerText = "The dog's “quick” brown-fox jumped... 'Hello,' said O’Neill's dogs' ''owner''. Ångström's rock'n'roll, 42— generously!"
+
+var tokenizerTokens [][2]string = [][2]string{
+	{"The", "the"},
+	{"dog's", "dog"},
+	{"quick", "quick"},
+	{"brown", "brown"},
+	{"fox", "fox"},
+	{"jumped", "jump"},
+	{"Hello", "hello"},
+	{"said", "said"},
+	{"O’Neill's", "o'neil"},
+	{"dogs'", "dog"},
+	{"owner'", "owner"},
+	{"Ångström's", "ångström"},
+	{"rock'n'roll", "rock'n'rol"},
+	{"42", "42"},
+	{"generously", "generous"},
+}
+
+func tokenize(t *testing.T, r io.Reader, input string) ([][2]string, error) {
+	var tokens [][2]string
+
+	tok := NewTokenizer(r, nil)
+	for tok.Next() {
+		token := tok.Token()
+		assert.Equal(t, input[token.Start:token.End], string(token.Text))
+		tokens = append(tokens, [2]string{string(token.Text), string(token.Stem)})
+	}
+
+	assert.False(t, tok.Next())
+
+	return tokens, tok.Err()
+}
+
+func TestTokenizer(t *testing.T) {
+	tokens, err := tokenize(t, strings.NewReader(tokenizerText), tokenizerText)
+	assert.NoError(t, err)
+	assert.Equal(t, tokenizerTokens, tokens)
+
+	tokens, err = tokenize(t, iotest.OneByteReader(strings.NewReader(tokenizerText)), tokenizerText)
+	assert.NoError(t, err)
+	assert.Equal(t, tokenizerTokens, tokens)
+
+	for _, input := range []string{"", " ", "'", "-- ... --", "’’"} {
+		tokens, err = tokenize(t, strings.NewReader(input), input)
+		assert.NoError(t, err)
+		assert.Empty(t, tokens, input)
+	}
+}
+
+func TestTokenizerStemmer(t *testing.T) {
+	tok := NewTokenizer(strings.NewReader("Kubernetes clusters"), NewEnglish(WithProtected("kubernetes")))
+
+	var stems []string
+	for tok.Next() {
+		stems = append(stems, string(tok.Token().Stem))
+	}
+
+	assert.Equal(t, []string{"Kubernetes", "cluster"}, stems)
+}
+
+func TestTokenizerLongWords(t *testing.T) {
+	input := "short " + strings.Repeat("a", MaxTokenLen+1) + " words " + strings.Repeat("b", MaxTokenLen)
+
+	tokens, err := tokenize(t, strings.NewReader(input), input)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]string{
+		{"short", "short"},
+		{"words", "word"},
+		{strings.Repeat("b", MaxTokenLen), strings.Repeat("b", MaxTokenLen)},
+	}, tokens)
+
+	// skipped at the end of the input too
+	input = "short " + strings.Repeat("a", MaxTokenLen+1)
+	tokens, err = tokenize(t, strings.NewReader(input), input)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]string{{"short", "short"}}, tokens)
+}
+
+func TestTokenizerHugeWord(t *testing.T) {
+	const size = 8 << 20
+
+	input := "short " + strings.Repeat("a", size) + "'s" + strings.Repeat("b", size) + " words"
+	tok := NewTokenizer(strings.NewReader(input), English)
+
+	var (
+		before, after runtime.MemStats
+		tokens        []string
+	)
+
+	runtime.ReadMemStats(&before)
+	for tok.Next() {
+		tokens = append(tokens, string(tok.Token().Text))
+	}
+	runtime.ReadMemStats(&after)
+
+	assert.NoError(t, tok.Err())
+	assert.Equal(t, []string{"short", "words"}, tokens)
+
+	// the buffers never hold more than MaxTokenLen bytes, however long the run
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(64*MaxTokenLen))
+}
+
+func TestTokenizerError(t *testing.T) {
+	errRead := errors.New("read failed")
+	input := "running dogs"
+
+	tokens, err := tokenize(t, io.MultiReader(strings.NewReader(input), iotest.ErrReader(errRead)), input)
+	assert.Equal(t, errRead, err)
+	assert.Equal(t, [][2]string{{"running", "run"}, {"dogs", "dog"}}, tokens)
+}
+
+func BenchmarkTokenizer(b *testing.B) {
+	words, _ := loadVoc("voc.txt", "output.txt")
+	text := strings.Join(words, " ")
+
+	b.SetBytes(int64(len(text)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tok := NewTokenizer(strings.NewReader(text), English)
+		for tok.Next() {
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/surgebase/porter2/internal/letters"
+)
+
+// Trace records what each step of the Porter2 algorithm did to a word. It is
+// returned by StemTrace, and is meant for understanding surprising stems, not
+// for production use.
+type Trace struct {
+	// Word is the word as given to StemTrace.
+	Word string
+
+	// Exception1 is true if the word is in the exception1 list, in which case
+	// Stem is the replacement, and none of the other fields are set.
+	Exception1 bool
+
+	// Preclude is the word after it's lower cased, curly apostrophes are turned
+	// into ', the initial ' is removed, and y's are marked as consonants (Y).
+	Preclude string
+
+	// R1 and R2 are the rune offsets of the R1 and R2 regions in Preclude.
+	R1, R2 int
+
+	// Steps are the steps in the order they ran, starting with step0.
+	Steps []TraceStep
+
+	// Exception2 is true if the word is in the exception2 list following step1a,
+	// in which case the rest of the steps did not run.
+	Exception2 bool
+
+	// Stem is the final result after postlude, the same as what Stem returns.
+	Stem string
+}
+
+// TraceStep records the effect of one step of the Porter2 algorithm.
+type TraceStep struct {
+	// Name is the name of the step, e.g., step2.
+	Name string
+
+	// Input and Output are the word before and after the step.
+	Input, Output string
+
+	// State is the end state of the longest suffix found by the step's state
+	// machine, i.e., the case in the step's switch f block, and Suffix is that
+	// suffix. Both are zero if no suffix is found, and for step1c and step5,
+	// which have no state machine.
+	State  int
+	Suffix string
+}
+
+// StemTrace stems word the same way as Stem, and records the effect of each
+// step along the way.
+func StemTrace(word string) Trace {
+	t := Trace{Word: word, Stem: word}
+
+	// If the word has two letters or less, leave it as it is.
+	if letters.IsShort(word) {
+		return t
+	}
+
+	rs := []rune(word)
+	for i, r := range rs {
+		rs[i] = lower(r)
+	}
+
+	var ex bool
+
+	if rs, ex = exception1(rs); ex {
+		t.Exception1 = true
+		t.Stem = string(rs)
+		return t
+	}
+
+	rs = preclude(rs)
+	t.Preclude = string(rs)
+
+	if len(rs) == 0 {
+		t.Stem = ""
+		return t
+	}
+
+	r1, r2 := markR1R2(rs)
+	t.R1, t.R2 = r1, r2
+
+	rs = t.step("step0", rs, suffix0[rune], step0[rune])
+	rs = t.step("step1a", rs, suffix1a[rune], step1a[rune])
+
+	if exception2(rs) {
+		t.Exception2 = true
+		t.Stem = string(rs)
+		return t
+	}
+
+	rs = t.step("step1b", rs, suffix1b[rune], func(rs []rune) []rune { return step1b(rs, r1) })
+	rs = t.step("step1c", rs, nil, step1c[rune])
+	rs = t.step("step2", rs, suffix2[rune], func(rs []rune) []rune { return step2(rs, r1) })
+	rs = t.step("step3", rs, suffix3[rune], func(rs []rune) []rune { return step3(rs, r1, r2) })
+	rs = t.step("step4", rs, suffix4[rune], func(rs []rune) []rune { return step4(rs, r2) })
+	rs = t.step("step5", rs, nil, func(rs []rune) []rune { return step5(rs, r1, r2) })
+
+	t.Stem = string(postlude(rs))
+
+	return t
+}
+
+// step runs the suffix state machine, if there is one, and then the step itself,
+// and records both in t.
+func (t *Trace) step(name string, rs []rune, suffix func([]rune) (int, int), step func([]rune) []rune) []rune {
+	ts := TraceStep{Name: name, Input: string(rs)}
+
+	if suffix != nil {
+		if m, f := suffix(rs); f != 0 {
+			ts.State = f
+			ts.Suffix = string(rs[len(rs)-m:])
+		}
+	}
+
+	rs = step(rs)
+	ts.Output = string(rs)
+	t.Steps = append(t.Steps, ts)
+
+	return rs
+}
+
+// String returns a table with one line per step, showing the word after the
+// step, and the suffix and state matched by the step's state machine, e.g.,
+//
+//	word      generously
+//	preclude  generously
+//	regions   gener|ous|ly  R1=5 R2=8
+//	...
+//	step1c    generousli
+//	step2     generous      ousli (state 43)
+//	step3     generous
+//	step4     generous      ous (state 36)
+//	step5     generous
+//	stem      generous
+func (t Trace) String() string {
+	var buf bytes.Buffer
+
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "word\t%s\t\n", t.Word)
+
+	switch {
+	case t.Exception1:
+		fmt.Fprintf(w, "exception1\t%s\t\n", t.Stem)
+
+	case len(t.Steps) > 0:
+		fmt.Fprintf(w, "preclude\t%s\t\n", t.Preclude)
+		fmt.Fprintf(w, "regions\t%s\tR1=%d R2=%d\n", regions(t.Preclude, t.R1, t.R2), t.R1, t.R2)
+
+		for _, s := range t.Steps {
+			if s.State != 0 {
+				fmt.Fprintf(w, "%s\t%s\t%s (state %d)\n", s.Name, s.Output, s.Suffix, s.State)
+			} else {
+				fmt.Fprintf(w, "%s\t%s\t\n", s.Name, s.Output)
+			}
+		}
+
+		if t.Exception2 {
+			fmt.Fprintf(w, "exception2\t%s\t\n", t.Stem)
+		}
+	}
+
+	fmt.Fprintf(w, "stem\t%s\t\n", t.Stem)
+	w.Flush()
+
+	// tabwriter pads the last cell of each line, so trim that back off
+	lines := strings.SplitAfter(buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \n") + "\n"
+	}
+
+	return strings.Join(lines[:len(lines)-1], "")
+}
+
+// regions returns word with a | at the start of R1 and R2, e.g., gener|ous|ly.
+func regions(word string, r1, r2 int) string {
+	rs := []rune(word)
+
+	var buf bytes.Buffer
+	for i, r := range rs {
+		if i == r1 {
+			buf.WriteByte('|')
+		}
+		if i == r2 {
+			buf.WriteByte('|')
+		}
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
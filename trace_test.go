@@ -0,0 +1,96 @@
+// Copyright (c) 2014 Dataence, LLC. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porter2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceVocOutput(t *testing.T) {
+	words, stems := loadVoc("voc.txt", "output.txt")
+
+	for i, word := range words {
+		assert.Equal(t, stems[i], StemTrace(word).Stem, word)
+	}
+}
+
+func TestTraceSteps(t *testing.T) {
+	tr := StemTrace("Generously")
+
+	assert.Equal(t, "Generously", tr.Word)
+	assert.Equal(t, "generously", tr.Preclude)
+	assert.Equal(t, 5, tr.R1)
+	assert.Equal(t, 8, tr.R2)
+	assert.False(t, tr.Exception1)
+	assert.False(t, tr.Exception2)
+	assert.Equal(t, "generous", tr.Stem)
+
+	var names []string
+	for _, s := range tr.Steps {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"step0", "step1a", "step1b", "step1c", "step2", "step3", "step4", "step5"}, names)
+
+	step2 := tr.Steps[4]
+	assert.Equal(t, "generousli", step2.Input)
+	assert.Equal(t, "generous", step2.Output)
+	assert.Equal(t, 43, step2.State)
+	assert.Equal(t, "ousli", step2.Suffix)
+
+	step3 := tr.Steps[5]
+	assert.Equal(t, 0, step3.State)
+	assert.Equal(t, "", step3.Suffix)
+}
+
+func TestTraceExceptions(t *testing.T) {
+	tr := StemTrace("skies")
+	assert.True(t, tr.Exception1)
+	assert.Equal(t, "sky", tr.Stem)
+	assert.Empty(t, tr.Steps)
+
+	tr = StemTrace("proceeds")
+	assert.True(t, tr.Exception2)
+	assert.Equal(t, "proceed", tr.Stem)
+	assert.Len(t, tr.Steps, 2)
+
+	tr = StemTrace("by")
+	assert.Equal(t, "by", tr.Stem)
+	assert.Empty(t, tr.Preclude)
+}
+
+func TestTraceString(t *testing.T) {
+	expect := `word      generously
+preclude  generously
+regions   gener|ous|ly  R1=5 R2=8
+step0     generously
+step1a    generously
+step1b    generously
+step1c    generousli
+step2     generous      ousli (state 43)
+step3     generous
+step4     generous      ous (state 36)
+step5     generous
+stem      generous
+`
+	assert.Equal(t, expect, StemTrace("generously").String())
+
+	expect = `word        skies
+exception1  sky
+stem        sky
+`
+	assert.Equal(t, expect, StemTrace("skies").String())
+}